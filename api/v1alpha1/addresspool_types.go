@@ -0,0 +1,68 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 一个集群中可以分配给成员的地址
+type ClusterAddresses struct {
+	Cluster string `json:"cluster"`
+	// ip或域名
+	// +kubebuilder:validation:MinItems=1
+	Addresses []string `json:"addresses"`
+}
+
+// AddressPoolSpec defines the desired state of AddressPool
+type AddressPoolSpec struct {
+	Clusters []ClusterAddresses `json:"clusters"`
+}
+
+// 地址分配给的成员，成员由MultiCloudMongoDB和成员service确定
+type AddressAllocation struct {
+	Cluster string `json:"cluster"`
+	Address string `json:"address"`
+	// 同namespace下的MultiCloudMongoDB名称
+	Owner   string `json:"owner"`
+	Service string `json:"service"`
+}
+
+// AddressPoolStatus defines the observed state of AddressPool
+type AddressPoolStatus struct {
+	Allocations []AddressAllocation `json:"allocations,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",type="date",name="Age"
+
+// AddressPool is the Schema for the addresspools API
+type AddressPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AddressPoolSpec   `json:"spec,omitempty"`
+	Status AddressPoolStatus `json:"status,omitempty"`
+}
+
+// 集群中可分配的地址
+func (p *AddressPool) Addresses(cluster string) []string {
+	for _, c := range p.Spec.Clusters {
+		if c.Cluster == cluster {
+			return c.Addresses
+		}
+	}
+	return nil
+}
+
+//+kubebuilder:object:root=true
+
+// AddressPoolList contains a list of AddressPool
+type AddressPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AddressPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AddressPool{}, &AddressPoolList{})
+}
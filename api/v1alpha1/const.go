@@ -28,6 +28,25 @@ const (
 	ServiceNameInfix = "mongodb"
 	// 标识arbiter节点
 	ArbiterName = "arbiter"
+
+	// 标识隐藏成员和延迟成员的service、sts和pod
+	LabelKeyMemberKind = "mongodb.k8s.io/member-kind"
+	MemberKindHidden   = "hidden"
+	MemberKindDelayed  = "delayed"
+
+	// 手动切换primary，MongoDB上为目标成员地址，MultiCloudMongoDB上为目标成员地址或集群名称
+	// 切换结束后保留，移除前暂停spec.memberPriority和primaryPreference对priority的修改，避免primary被切换回去
+	AnnotationKeySwitchover = "mongodb.fedstate.io/switchover"
+	// 故障集群上的投票成员达到多数时，设置为true批准强制修改副本集配置，创建运维操作后移除
+	AnnotationKeyApproveForceReconfig = "mongodb.fedstate.io/approve-force-reconfig"
+
+	// 外部调度器写入的调度结果，存在时覆盖内置调度器的结果
+	AnnotationKeySchedulerResult = "schedulerResult"
+	// 内置调度器计算的调度结果
+	AnnotationKeyBuiltinSchedulerResult = "mongodb.fedstate.io/builtin-scheduler-result"
+
+	// 控制面分配给成员service的地址：地址池中的地址或derived service的域名，成员集群使用该地址代替集群vip
+	AnnotationKeyMemberAddress = "mongodb.fedstate.io/member-address"
 )
 
 var (
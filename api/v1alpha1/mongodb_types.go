@@ -43,8 +43,18 @@ type MongoDBSpec struct {
 	Config              []ConfigVar          `json:"config,omitempty"`
 	Members             int                  `json:"members,omitempty"`
 	Arbiter             bool                 `json:"arbiter,omitempty"`
+	ArbiterSpec         *ArbiterSpec         `json:"arbiterSpec,omitempty"`
 	Pause               bool                 `json:"pause,omitempty"`
 	RsInit              bool                 `json:"rsInit,omitempty"`
+	Expose              ExposeSetting        `json:"expose,omitempty"`
+	// 复制延迟超过该值时成员的member-healthy readiness gate为False，默认60秒
+	MaxReplicationLagSeconds int64 `json:"maxReplicationLagSeconds,omitempty"`
+	// 按成员序号覆盖数据节点的资源和调度配置，按顺序生效，后面的配置覆盖前面的配置
+	MemberOverrides []MemberOverride `json:"memberOverrides,omitempty"`
+	// 本集群数据节点在副本集中的priority，为空时不修改
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	MemberPriority *int `json:"memberPriority,omitempty"`
 }
 
 type ConfigVar struct {
@@ -82,6 +92,40 @@ type PodSpec struct {
 	RestartPolicy             corev1.RestartPolicy              `json:"restartPolicy,omitempty" protobuf:"bytes,3,opt,name=restartPolicy,casttype=RestartPolicy"`
 	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty" protobuf:"bytes,22,opt,name=tolerations"`
 	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty" patchStrategy:"merge" patchMergeKey:"topologyKey" protobuf:"bytes,33,opt,name=topologySpreadConstraints"`
+
+	// mongod容器的探针，未设置检查方式时使用默认检查，只覆盖时间和阈值参数
+	StartupProbe   *corev1.Probe `json:"startupProbe,omitempty"`
+	LivenessProbe  *corev1.Probe `json:"livenessProbe,omitempty"`
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+	// exporter容器的探针
+	ExporterLivenessProbe  *corev1.Probe `json:"exporterLivenessProbe,omitempty"`
+	ExporterReadinessProbe *corev1.Probe `json:"exporterReadinessProbe,omitempty"`
+
+	// pod优雅退出时间，primary需要在此时间内完成stepDown，默认60秒
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// 追加到pod模板上的labels和annotations，不会覆盖operator使用的label
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	PriorityClassName  string  `json:"priorityClassName,omitempty"`
+	ServiceAccountName string  `json:"serviceAccountName,omitempty"`
+	RuntimeClassName   *string `json:"runtimeClassName,omitempty"`
+
+	// 额外的sidecar容器和init容器，如日志采集、服务网格等
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Containers []corev1.Container `json:"containers,omitempty"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+	// 额外的volume，通过volumeMounts挂载到mongod容器，sidecar容器自行声明挂载
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Volumes      []corev1.Volume      `json:"volumes,omitempty"`
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// mongod容器的securityContext
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
 }
 
 // MongoDBStatus defines the observed state of MongoDB
@@ -96,11 +140,43 @@ type MongoDBStatus struct {
 	ExternalAddress string `json:"externalAddress,omitempty"`
 
 	ReplSet []mgo.MemberStatus `json:"replset,omitempty"`
+	// 本集群成员service名称和副本集成员地址的对应关系，供控制面生成hostconf
+	MemberAddrs map[string]string `json:"memberAddrs,omitempty"`
 
 	CurrentRevision string      `json:"currentRevision,omitempty"`
 	CurrentInfo     CurrentInfo `json:"currentInfo,omitempty"`
 
 	Conditions []MongoCondition `json:"conditions,omitempty"`
+
+	// 最近一次手动切换primary的过程
+	Switchover *SwitchoverStatus `json:"switchover,omitempty"`
+}
+
+type SwitchoverPhase string
+
+const (
+	SwitchoverPhaseRunning   SwitchoverPhase = "Running"
+	SwitchoverPhaseSucceeded SwitchoverPhase = "Succeeded"
+	SwitchoverPhaseFailed    SwitchoverPhase = "Failed"
+)
+
+type SwitchoverStatus struct {
+	// 目标成员地址
+	Target string `json:"target,omitempty"`
+	// 目标成员所在集群，仅MultiCloudMongoDB使用
+	Cluster string `json:"cluster,omitempty"`
+	// 切换前的primary
+	From    string          `json:"from,omitempty"`
+	Phase   SwitchoverPhase `json:"phase,omitempty"`
+	Message string          `json:"message,omitempty"`
+	// 目标成员切换前的priority，切换结束后恢复
+	OriginalPriority *int         `json:"originalPriority,omitempty"`
+	StartTime        *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime   *metav1.Time `json:"completionTime,omitempty"`
+}
+
+func (s *SwitchoverStatus) IsFinished() bool {
+	return s != nil && (s.Phase == SwitchoverPhaseSucceeded || s.Phase == SwitchoverPhaseFailed)
 }
 
 type CurrentInfo struct {
@@ -112,6 +188,8 @@ type CurrentInfo struct {
 
 	CustomConfig string `json:"customConfig,omitempty"`
 	Members      int    `json:"members"`
+	// MongoDBOpsRequest要求滚动重启的时间
+	RestartedAt string `json:"restartedAt,omitempty"`
 }
 
 type MongoCondition struct {
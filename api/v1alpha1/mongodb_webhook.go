@@ -130,6 +130,14 @@ func (r *MongoDB) ValidateUpdate(old runtime.Object) error {
 		return errors.New("spec.memberConfigRef is forbidden to change while updating")
 	}
 
+	// 成员地址由暴露方式决定，修改后副本集配置中的地址将失效
+	if r.Spec.Expose.GetType() != old.(*MongoDB).Spec.Expose.GetType() {
+		return errors.New("spec.expose.type is forbidden to change while updating")
+	}
+	if r.Spec.Expose.SplitHorizon != old.(*MongoDB).Spec.Expose.SplitHorizon {
+		return errors.New("spec.expose.splitHorizon is forbidden to change while updating")
+	}
+
 	if old.(*MongoDB).Spec.RootPassword != "" && r.Spec.RootPassword != old.(*MongoDB).Spec.RootPassword {
 		return errors.New("spec.rootPassword is forbidden to change while updating")
 	}
@@ -0,0 +1,93 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type OpsType string
+
+const (
+	// 滚动重启，先重启secondary，最后stepDown后重启primary
+	OpsTypeRestart OpsType = "Restart"
+	// 当前primary下线，重新选举
+	OpsTypeStepDown OpsType = "StepDown"
+	// 清空成员数据后重新全量同步
+	OpsTypeResync OpsType = "Resync"
+	// 整理成员上集合的存储空间
+	OpsTypeCompact OpsType = "Compact"
+	// 校验成员上的集合，有损坏时重新全量同步
+	OpsTypeRepair OpsType = "Repair"
+	// 副本集没有primary时强制修改副本集配置
+	OpsTypeReconfigure OpsType = "Reconfigure"
+	// 轮换operator使用的clusterAdmin和clusterMonitor用户密码，只能在单集群MongoDB上使用
+	OpsTypeRotateCredentials OpsType = "RotateCredentials"
+)
+
+type OpsPhase string
+
+const (
+	OpsPhasePending   OpsPhase = "Pending"
+	OpsPhaseRunning   OpsPhase = "Running"
+	OpsPhaseSucceeded OpsPhase = "Succeeded"
+	OpsPhaseFailed    OpsPhase = "Failed"
+)
+
+// MongoDBOpsRequestSpec defines the desired state of MongoDBOpsRequest
+type MongoDBOpsRequestSpec struct {
+	// 同namespace下的MongoDB名称
+	MongoDBRef string `json:"mongodbRef"`
+	// +kubebuilder:validation:Enum=Restart;StepDown;Resync;Compact;Repair;Reconfigure;RotateCredentials
+	Type OpsType `json:"type"`
+	// 目标成员地址，Resync、Compact、Repair需要指定
+	Member string `json:"member,omitempty"`
+	// Compact、Repair处理的数据库，为空时处理除local外的所有数据库
+	Database string `json:"database,omitempty"`
+	// Reconfigure后保留的成员地址，为空时使用hostconf中的成员
+	Members []string `json:"members,omitempty"`
+	// Reconfigure时跳过副本集没有primary、移除的成员不可达的检查
+	Force bool `json:"force,omitempty"`
+	// 操作超时时间，超时后操作失败
+	// +kubebuilder:default:=1800
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+}
+
+// MongoDBOpsRequestStatus defines the observed state of MongoDBOpsRequest
+type MongoDBOpsRequestStatus struct {
+	Phase          OpsPhase     `json:"phase,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+func (s MongoDBOpsRequestStatus) IsFinished() bool {
+	return s.Phase == OpsPhaseSucceeded || s.Phase == OpsPhaseFailed
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:JSONPath=".spec.mongodbRef",type="string",name="MONGODB"
+//+kubebuilder:printcolumn:JSONPath=".spec.type",type="string",name="TYPE"
+//+kubebuilder:printcolumn:JSONPath=".status.phase",type="string",name="PHASE"
+//+kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",type="date",name="Age"
+
+// MongoDBOpsRequest is the Schema for the mongodbopsrequests API
+type MongoDBOpsRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBOpsRequestSpec   `json:"spec,omitempty"`
+	Status MongoDBOpsRequestStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MongoDBOpsRequestList contains a list of MongoDBOpsRequest
+type MongoDBOpsRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBOpsRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBOpsRequest{}, &MongoDBOpsRequestList{})
+}
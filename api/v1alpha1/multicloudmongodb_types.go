@@ -46,6 +46,26 @@ type MultiCloudMongoDBSpec struct {
 	Config            ConfigSetting    `json:"config,omitempty"`
 	Scheduler         SchedulerSetting `json:"scheduler,omitempty"`
 	SpreadConstraints SpreadConstraint `json:"spreadConstraints,omitempty"`
+	Expose            ExposeSetting    `json:"expose,omitempty"`
+	// 透传到成员集群MongoDB的pod配置
+	PodSpec *PodSpec `json:"podSpec,omitempty"`
+	// 仲裁节点的资源和调度配置，调度结果中标记arbiter且副本数为0的集群只部署仲裁节点
+	ArbiterSpec *ArbiterSpec `json:"arbiterSpec,omitempty"`
+	// 按集群覆盖数据节点的资源和调度配置
+	ClusterOverrides []ClusterOverride `json:"clusterOverrides,omitempty"`
+	// 隐藏成员和延迟成员
+	HiddenMembers *HiddenMemberSetting `json:"hiddenMembers,omitempty"`
+	// primary优先所在的集群，按顺序优先级递减，转换为各集群成员的priority
+	PrimaryPreference []string `json:"primaryPreference,omitempty"`
+	// 将副本集迁移到目标集群，只支持内置调度器
+	Migration *MigrationSpec `json:"migration,omitempty"`
+}
+
+// 迁移：先在目标集群增加成员，成员追上oplog后将primary切换到目标集群，再移除源集群上的成员及其service、pp和op
+type MigrationSpec struct {
+	// 迁移完成后成员只部署在这些集群
+	// +kubebuilder:validation:MinItems=1
+	TargetClusters []string `json:"targetClusters"`
 }
 
 type MemberSetting struct {
@@ -71,7 +91,12 @@ type SpreadConstraint struct {
 	NodeSelect                map[string]string           `json:"nodeSelect,omitempty"`
 	TopologySpreadConstraints map[string]string           `json:"topologySpreadConstraints,omitempty"`
 	SpreadConstraints         []v1alpha1.SpreadConstraint `json:"spreadConstraints,omitempty"`
-	AllowDynamicScheduler     bool                        `json:"allowDynamicScheduler,omitempty"`
+	// 成员集群NotReady超过宽限期后，内置调度器将该集群的成员重新调度到健康的集群
+	AllowDynamicScheduler bool `json:"allowDynamicScheduler,omitempty"`
+	// 集群NotReady后重新调度前的宽限期
+	// +kubebuilder:default:=300
+	// +kubebuilder:validation:Minimum=0
+	FailoverGracePeriodSeconds int32 `json:"failoverGracePeriodSeconds,omitempty"`
 }
 
 // SchedulerSetting
@@ -79,11 +104,34 @@ type SpreadConstraint struct {
 //	@Description: 调度设置
 type SchedulerSetting struct {
 	SchedulerName *string `json:"schedulerName,omitempty"`
+	// 内置调度器的副本分配方式：Uniform各集群均分，Weighting按权重分配，PrimaryBiased优先集群分配多数成员
 	// +kubebuilder:default:=Uniform
-	// +kubebuilder:validation:Enum=Uniform;Weighting
+	// +kubebuilder:validation:Enum=Uniform;Weighting;PrimaryBiased
 	SchedulerMode *string             `json:"schedulerMode,omitempty"`
 	Affinity      *corev1.Affinity    `json:"affinity,omitempty"`
 	Tolerations   []corev1.Toleration `json:"tolerations,omitempty"`
+	// Weighting模式下各集群的权重，设置后未列出的集群不参与调度
+	ClusterWeights []ClusterWeight `json:"clusterWeights,omitempty"`
+	// 多数派保护，不设置时不校验
+	MajorityGuard *MajorityGuard `json:"majorityGuard,omitempty"`
+	// 排空的集群，内置调度器不再调度成员到这些集群；替换成员完成同步并切走primary后，这些集群上的成员缩容到0
+	ExcludedClusters []string `json:"excludedClusters,omitempty"`
+}
+
+// 任何一个集群(或分组)都不能持有半数及以上的投票(包含仲裁节点)，否则该集群故障后副本集无法选出primary
+type MajorityGuard struct {
+	// 不满足时的处理方式：Reject拒绝调度结果，Arbiter将仲裁节点放到其他集群(优先没有数据成员的集群)
+	// +kubebuilder:default:=Reject
+	// +kubebuilder:validation:Enum=Reject;Arbiter
+	Policy string `json:"policy,omitempty"`
+	// 按成员集群的label分组校验，例如region，没有该label的集群单独作为一组
+	GroupByLabel string `json:"groupByLabel,omitempty"`
+}
+
+type ClusterWeight struct {
+	Cluster string `json:"cluster"`
+	// +kubebuilder:validation:Minimum=1
+	Weight int32 `json:"weight"`
 }
 
 // ImageSetting
@@ -119,6 +167,9 @@ type ConfigSetting struct {
 	ConfigSet map[string]string `json:"configSet,omitempty"`
 	ConfigRef *string           `json:"configRef,omitempty"`
 	Arbiter   bool              `json:"arbiter,omitempty"`
+	// 仲裁节点所在集群：Auto选择没有数据成员的集群，没有时选择成员最少的集群，数据成员分布变化时自动迁移；
+	// 也可以指定witness集群，集群不存在时记录到condition中。Auto不区分大小写，不设置时使用调度结果中标记的集群
+	ArbiterPlacement string `json:"arbiterPlacement,omitempty"`
 }
 
 // MultiCloudMongoDBStatus 描述控制面CR状态
@@ -128,6 +179,92 @@ type MultiCloudMongoDBStatus struct {
 	State        State              `json:"state,omitempty"`        // 服务状态
 	Result       []*ServiceTopology `json:"result,omitempty"`       // 服务分发结果
 	Conditions   []ServerCondition  `json:"conditions,omitempty"`   // 服务condition
+	Switchover   *SwitchoverStatus  `json:"switchover,omitempty"`   // 最近一次手动切换primary的过程
+	Drain        *DrainStatus       `json:"drain,omitempty"`        // 集群排空的过程
+	Migration    *MigrationStatus   `json:"migration,omitempty"`    // 迁移的过程
+	Failover     *FailoverStatus    `json:"failover,omitempty"`     // 最近一次集群故障后的重新调度
+}
+
+type FailoverStatus struct {
+	// NotReady超过宽限期的集群
+	FailedClusters []string `json:"failedClusters,omitempty"`
+	// 故障集群上的投票成员达到多数时，通过运维操作强制修改副本集配置，需要通过annotation批准
+	ForceReconfig bool `json:"forceReconfig,omitempty"`
+	// 强制修改副本集配置后保留的成员地址
+	Members []string `json:"members,omitempty"`
+	// 执行强制修改副本集配置的集群
+	Cluster string `json:"cluster,omitempty"`
+	// 强制修改副本集配置的MongoDBOpsRequest名称，批准后创建
+	OpsRequest string       `json:"opsRequest,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	Time       *metav1.Time `json:"time,omitempty"`
+}
+
+type DrainPhase string
+
+const (
+	// 等待替换成员完成初始同步
+	DrainPhaseWaitingSync DrainPhase = "WaitingSync"
+	// 等待primary切换到其他集群
+	DrainPhaseSteppingDown DrainPhase = "SteppingDown"
+	// 排空集群上的成员缩容到0
+	DrainPhaseScalingDown DrainPhase = "ScalingDown"
+	DrainPhaseCompleted   DrainPhase = "Completed"
+)
+
+type DrainStatus struct {
+	Clusters       []string     `json:"clusters,omitempty"`
+	Phase          DrainPhase   `json:"phase,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+type MigrationPhase string
+
+const (
+	MigrationPhaseRunning   MigrationPhase = "Running"
+	MigrationPhaseCompleted MigrationPhase = "Completed"
+)
+
+type MigrationStepName string
+
+const (
+	// 在目标集群增加成员
+	MigrationStepAddMembers MigrationStepName = "AddMembers"
+	// 等待目标集群的成员完成初始同步
+	MigrationStepSyncMembers MigrationStepName = "SyncMembers"
+	// 将primary切换到目标集群
+	MigrationStepTransferPrimary MigrationStepName = "TransferPrimary"
+	// 将源集群上的成员缩容到0
+	MigrationStepRemoveMembers MigrationStepName = "RemoveMembers"
+	// 清理源集群上的service、pp和op
+	MigrationStepCleanup MigrationStepName = "Cleanup"
+)
+
+type MigrationStepState string
+
+const (
+	MigrationStepPending MigrationStepState = "Pending"
+	MigrationStepRunning MigrationStepState = "Running"
+	MigrationStepDone    MigrationStepState = "Done"
+)
+
+type MigrationStep struct {
+	Name           MigrationStepName  `json:"name"`
+	State          MigrationStepState `json:"state,omitempty"`
+	Message        string             `json:"message,omitempty"`
+	StartTime      *metav1.Time       `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time       `json:"completionTime,omitempty"`
+}
+
+type MigrationStatus struct {
+	SourceClusters []string        `json:"sourceClusters,omitempty"`
+	TargetClusters []string        `json:"targetClusters,omitempty"`
+	Phase          MigrationPhase  `json:"phase,omitempty"`
+	Steps          []MigrationStep `json:"steps,omitempty"`
+	StartTime      *metav1.Time    `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time    `json:"completionTime,omitempty"`
 }
 
 // ServiceTopology
@@ -137,6 +274,7 @@ type ServiceTopology struct {
 	ReplicasetStatus    *int              `json:"replicasetStatus,omitempty"`
 	ReplicasetSpec      *int              `json:"replicasetSpec,omitempty"`
 	ConnectAddrWithRole map[string]string `json:"connectAddrWithRole,omitempty"`
+	MemberAddrs         map[string]string `json:"memberAddrs,omitempty"` // 成员service与地址的对应关系
 	Cluster             string            `json:"cluster,omitempty"`
 	State               MongoState        `json:"state,omitempty"`
 	CurrentRevision     string            `json:"currentRevision,omitempty"`
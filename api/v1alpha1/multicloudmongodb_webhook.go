@@ -17,17 +17,21 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/fedstate/fedstate/pkg/logi"
+	"github.com/fedstate/fedstate/pkg/model"
 	"github.com/fedstate/fedstate/pkg/util"
 )
 
@@ -37,8 +41,14 @@ var multicloudmongodblog = logi.Log.With(zap.String("webhook", "MultiCloudMongoD
 const (
 	defaultMongoImage = "mongo:3.6"
 
-	UniformScheduling = "Uniform"
-	WeightScheduling  = "Weighting"
+	UniformScheduling       = "Uniform"
+	WeightScheduling        = "Weighting"
+	PrimaryBiasedScheduling = "PrimaryBiased"
+
+	ArbiterPlacementAuto = "Auto"
+
+	MajorityGuardReject  = "Reject"
+	MajorityGuardArbiter = "Arbiter"
 )
 
 func (r *MultiCloudMongoDB) SetupWebhookWithManager(mgr ctrl.Manager) error {
@@ -99,7 +109,19 @@ var _ webhook.Validator = &MultiCloudMongoDB{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *MultiCloudMongoDB) ValidateCreate() error {
 	multicloudmongodblog.Infof("validate create name: %s", r.Name)
-	return nil
+	if err := r.validateArbiterPlacement(); err != nil {
+		return err
+	}
+	if err := r.validateMigration(); err != nil {
+		return err
+	}
+	if err := r.validateExcludedClusters(); err != nil {
+		return err
+	}
+	if err := r.validateAddressPool(); err != nil {
+		return err
+	}
+	return r.validateMajority()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -109,10 +131,147 @@ func (r *MultiCloudMongoDB) ValidateUpdate(old runtime.Object) error {
 		return fmt.Errorf("number of replicas cannot be less than 1, name: %s", r.Name)
 	}
 
-	if r.Annotations["schedulerResult"] == "" {
-		return fmt.Errorf("not schedulerResult, name: %s", r.Name)
+	if r.Spec.Expose.GetType() != old.(*MultiCloudMongoDB).Spec.Expose.GetType() {
+		return fmt.Errorf("spec.expose.type is forbidden to change while updating, name: %s", r.Name)
+	}
+	if r.Spec.Expose.SplitHorizon != old.(*MultiCloudMongoDB).Spec.Expose.SplitHorizon {
+		return fmt.Errorf("spec.expose.splitHorizon is forbidden to change while updating, name: %s", r.Name)
+	}
+	if r.Spec.Expose.AddressPool != old.(*MultiCloudMongoDB).Spec.Expose.AddressPool {
+		return fmt.Errorf("spec.expose.addressPool is forbidden to change while updating, name: %s", r.Name)
+	}
+
+	// ClusterIP和Headless的成员地址只能在集群内解析，不能跨集群部署
+	// 未指定外部调度结果时校验内置调度器的结果
+	annotationSchedulerResult, ok := r.Annotations[AnnotationKeySchedulerResult]
+	if !ok {
+		annotationSchedulerResult, ok = r.Annotations[AnnotationKeyBuiltinSchedulerResult]
+	}
+	if r.Spec.Expose.IsClusterLocal() && ok {
+		schedulerResult := &model.SchedulerResult{}
+		if err := json.Unmarshal([]byte(annotationSchedulerResult), schedulerResult); err != nil {
+			return fmt.Errorf("invalid schedulerResult, name: %s, err: %v", r.Name, err)
+		}
+		clusters := 0
+		for _, cwr := range schedulerResult.ClusterWithReplicaset {
+			if cwr.Replicaset > 0 || cwr.Arbiter {
+				clusters++
+			}
+		}
+		if clusters > 1 {
+			return fmt.Errorf("spec.expose.type %s only supports a single cluster, name: %s", r.Spec.Expose.GetType(), r.Name)
+		}
+	}
+
+	if err := r.validateArbiterPlacement(); err != nil {
+		return err
+	}
+	if err := r.validateMigration(); err != nil {
+		return err
+	}
+	if err := r.validateAddressPool(); err != nil {
+		return err
+	}
+	return r.validateMajority()
+}
+
+func (r *MultiCloudMongoDB) validateArbiterPlacement() error {
+	if r.Spec.Config.ArbiterPlacement == "" {
+		return nil
+	}
+	if !r.Spec.Config.Arbiter {
+		return fmt.Errorf("spec.config.arbiterPlacement requires spec.config.arbiter, name: %s", r.Name)
 	}
+	// 仲裁节点可能放到没有数据成员的集群
+	if r.Spec.Expose.IsClusterLocal() {
+		return fmt.Errorf("spec.config.arbiterPlacement is not supported with spec.expose.type %s, name: %s", r.Spec.Expose.GetType(), r.Name)
+	}
+	// 指定的集群是否存在由控制面校验
+	if !r.Spec.Config.IsAutoArbiterPlacement() {
+		if errs := validation.IsDNS1123Subdomain(r.Spec.Config.ArbiterPlacement); len(errs) > 0 {
+			return fmt.Errorf("spec.config.arbiterPlacement must be %s or a cluster name, name: %s, err: %s",
+				ArbiterPlacementAuto, r.Name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
 
+// 排除集群由内置调度器迁出成员，外部调度结果不会处理
+func (r *MultiCloudMongoDB) validateExcludedClusters() error {
+	if len(r.Spec.Scheduler.ExcludedClusters) == 0 {
+		return nil
+	}
+	if _, ok := r.Annotations[AnnotationKeySchedulerResult]; ok {
+		return fmt.Errorf("spec.scheduler.excludedClusters requires the builtin scheduler, remove annotation %s, name: %s", AnnotationKeySchedulerResult, r.Name)
+	}
+	return nil
+}
+
+func (r *MultiCloudMongoDB) validateMigration() error {
+	if r.Spec.Migration == nil {
+		return nil
+	}
+	if _, ok := r.Annotations[AnnotationKeySchedulerResult]; ok {
+		return fmt.Errorf("spec.migration requires the builtin scheduler, remove annotation %s, name: %s", AnnotationKeySchedulerResult, r.Name)
+	}
+	excluded := make(map[string]bool, len(r.Spec.Scheduler.ExcludedClusters))
+	for _, c := range r.Spec.Scheduler.ExcludedClusters {
+		excluded[c] = true
+	}
+	for _, c := range r.Spec.Migration.TargetClusters {
+		if excluded[c] {
+			return fmt.Errorf("migration target cluster %s is in spec.scheduler.excludedClusters, name: %s", c, r.Name)
+		}
+	}
+	return nil
+}
+
+// 校验多数派保护，按label分组需要成员集群的信息，由控制面校验
+func (r *MultiCloudMongoDB) validateMajority() error {
+	guard := r.Spec.Scheduler.MajorityGuard
+	if guard == nil {
+		return nil
+	}
+	if r.Spec.Expose.IsClusterLocal() {
+		return fmt.Errorf("spec.scheduler.majorityGuard requires members in multiple clusters, spec.expose.type %s only supports a single cluster, name: %s",
+			r.Spec.Expose.GetType(), r.Name)
+	}
+	if r.Spec.Scheduler.SchedulerMode != nil && *r.Spec.Scheduler.SchedulerMode == PrimaryBiasedScheduling {
+		return fmt.Errorf("spec.scheduler.majorityGuard conflicts with schedulerMode %s, which places a majority of members in one cluster, name: %s",
+			PrimaryBiasedScheduling, r.Name)
+	}
+
+	annotationSchedulerResult, ok := r.Annotations[AnnotationKeySchedulerResult]
+	if !ok {
+		annotationSchedulerResult, ok = r.Annotations[AnnotationKeyBuiltinSchedulerResult]
+	}
+	if !ok {
+		return nil
+	}
+	schedulerResult := &model.SchedulerResult{}
+	if err := json.Unmarshal([]byte(annotationSchedulerResult), schedulerResult); err != nil {
+		return fmt.Errorf("invalid schedulerResult, name: %s, err: %v", r.Name, err)
+	}
+	votes := schedulerResult.Votes(r.Spec.Config.Arbiter)
+	// Arbiter策略由控制面将仲裁节点放到其他集群(这里用空集群名表示)，只拒绝放置后仍然不满足的结果
+	if guard.Policy == MajorityGuardArbiter {
+		votes = schedulerResult.Votes(false)
+		votes[""] = 1
+	}
+	if holder, n, total := model.MajorityHolder(votes); holder != "" {
+		return fmt.Errorf("cluster %s holds %d of %d votes, the replica set cannot elect a primary if it fails, name: %s",
+			holder, n, total, r.Name)
+	}
+	return nil
+}
+
+func (r *MultiCloudMongoDB) validateAddressPool() error {
+	if r.Spec.Expose.AddressPool == "" {
+		return nil
+	}
+	if t := r.Spec.Expose.GetType(); t != ExposeTypeNodePort && t != ExposeTypeLoadBalancer {
+		return fmt.Errorf("spec.expose.addressPool is not supported with spec.expose.type %s, name: %s", t, r.Name)
+	}
 	return nil
 }
 
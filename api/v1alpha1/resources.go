@@ -1,8 +1,140 @@
 package v1alpha1
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
 
 type ResourceSetting struct {
 	Limits   corev1.ResourceList `json:"limits,omitempty"`
 	Requests corev1.ResourceList `json:"requests,omitempty"`
 }
+
+// 覆盖数据节点的资源和调度配置
+type MemberOverride struct {
+	// 成员序号，对应成员sts名称的序号后缀，为空时作用于所有数据节点
+	Member       *int                `json:"member,omitempty"`
+	Resources    *ResourceSetting    `json:"resources,omitempty"`
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+}
+
+// 覆盖指定集群上数据节点的配置，通过OverridePolicy下发到成员集群
+type ClusterOverride struct {
+	Cluster   string           `json:"cluster"`
+	Overrides []MemberOverride `json:"overrides,omitempty"`
+}
+
+// 隐藏成员和延迟成员，不计入副本数，不对客户端可见
+type HiddenMemberSetting struct {
+	// 隐藏成员数量，用于分析、备份
+	Hidden int `json:"hidden,omitempty"`
+	// 延迟成员数量，用于防止误操作
+	Delayed int `json:"delayed,omitempty"`
+	// 延迟成员落后primary的秒数
+	// +kubebuilder:default:=3600
+	DelaySeconds int64 `json:"delaySeconds,omitempty"`
+	// 部署的集群，需要是调度结果中有数据节点的集群，为空时部署在成员最多的集群
+	Cluster string `json:"cluster,omitempty"`
+}
+
+func (h *HiddenMemberSetting) GetHidden() int {
+	if h == nil {
+		return 0
+	}
+	return h.Hidden
+}
+
+func (h *HiddenMemberSetting) GetDelayed() int {
+	if h == nil {
+		return 0
+	}
+	return h.Delayed
+}
+
+// 未设置时延迟一小时
+func (h *HiddenMemberSetting) GetDelaySeconds() int64 {
+	if h == nil || h.DelaySeconds == 0 {
+		return 3600
+	}
+	return h.DelaySeconds
+}
+
+func (h *HiddenMemberSetting) GetCluster() string {
+	if h == nil {
+		return ""
+	}
+	return h.Cluster
+}
+
+// 仲裁节点不保存数据，使用独立的资源、调度和存储配置
+type ArbiterSpec struct {
+	// 为空时使用数据节点的resources
+	Resources    *ResourceSetting    `json:"resources,omitempty"`
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+	// 为空时仲裁节点不挂载PV
+	Persistence *PersistenceSpec `json:"persistence,omitempty"`
+}
+
+type ExposeType string
+
+const (
+	// 通过集群vip和nodeport暴露成员，默认方式
+	ExposeTypeNodePort ExposeType = "NodePort"
+	// 通过LoadBalancer的ingress地址暴露成员
+	ExposeTypeLoadBalancer ExposeType = "LoadBalancer"
+	// 通过ClusterIP service的dns暴露成员，只适用于单集群
+	ExposeTypeClusterIP ExposeType = "ClusterIP"
+	// 通过headless service的dns暴露成员，只适用于单集群
+	ExposeTypeHeadless ExposeType = "Headless"
+	// 成员使用宿主机网络，通过节点ip暴露
+	ExposeTypeHostNetwork ExposeType = "HostNetwork"
+	// 通过ServiceExport/ServiceImport导出成员service，使用karmada在各集群创建的derived service的dns暴露成员，
+	// 不依赖集群vip和nodeport
+	ExposeTypeMultiClusterService ExposeType = "MultiClusterService"
+)
+
+// ExposeSetting
+//
+//	@Description: 副本集成员的暴露方式，决定写入副本集配置的成员地址
+type ExposeSetting struct {
+	// +kubebuilder:default:=NodePort
+	// +kubebuilder:validation:Enum=NodePort;LoadBalancer;ClusterIP;Headless;HostNetwork;MultiClusterService
+	Type ExposeType `json:"type,omitempty"`
+	// 添加到成员service上的annotations，如云厂商LoadBalancer的配置
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// 为成员配置internal(集群内service dns)和external(成员地址)两个horizon，
+	// 需要MongoDB 4.2及以上版本，客户端通过TLS SNI选择horizon
+	SplitHorizon bool `json:"splitHorizon,omitempty"`
+	// 同namespace下的AddressPool名称，只在MultiCloudMongoDB上生效。设置后从地址池为每个成员分配地址，
+	// NodePort方式下代替集群vip，LoadBalancer方式下作为service的loadBalancerIP
+	AddressPool string `json:"addressPool,omitempty"`
+}
+
+// 未设置时保持vip+nodeport的方式
+func (e ExposeSetting) GetType() ExposeType {
+	if e.Type == "" {
+		return ExposeTypeNodePort
+	}
+	return e.Type
+}
+
+// 成员地址只在集群内部可以解析
+func (e ExposeSetting) IsClusterLocal() bool {
+	return e.GetType() == ExposeTypeClusterIP || e.GetType() == ExposeTypeHeadless
+}
+
+// 成员地址需要等待成员集群分配后上报
+func (e ExposeSetting) IsReportedByMember() bool {
+	return e.GetType() == ExposeTypeLoadBalancer || e.GetType() == ExposeTypeHostNetwork
+}
+
+// 仲裁节点自动选择集群，Auto不区分大小写
+func (c ConfigSetting) IsAutoArbiterPlacement() bool {
+	return strings.EqualFold(c.ArbiterPlacement, ArbiterPlacementAuto)
+}
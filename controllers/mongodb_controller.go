@@ -25,12 +25,17 @@ import (
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
@@ -39,6 +44,7 @@ import (
 	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
 	"github.com/fedstate/fedstate/pkg/controller/mongodb/core"
 	"github.com/fedstate/fedstate/pkg/controller/mongodb/mode"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
 	"github.com/fedstate/fedstate/pkg/event"
 	"github.com/fedstate/fedstate/pkg/logi"
 	"github.com/fedstate/fedstate/pkg/metrics"
@@ -62,6 +68,10 @@ type MongoDBReconciler struct {
 //+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=*
 //+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=*
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;create;update;patch;watch
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=middleware.fedstate.io,resources=mongodbopsrequests,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=middleware.fedstate.io,resources=mongodbopsrequests/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -130,6 +140,9 @@ func (r *MongoDBReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			return reconcile.Result{}, err
 		}
 	}
+	// 2. 处理运维操作，操作执行中时缩短调和间隔
+	opsRunning := r.handleOpsRequests(cr, b, log)
+
 	// 3. Pre-create pre-operations such as secret, configMap
 	log.Debugf("create secret and cm for %s", cr.Name)
 	if err := m.PreConfig(); err != nil {
@@ -161,7 +174,11 @@ func (r *MongoDBReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		// 说明 CR 状态发生变化，经过调和后，需要变成成功的状态
 		r.Event.CustomNormalEvent(cr, "ReconcileMongoDBSuccess", fmt.Sprintf("Mongo Name: %s", b.GetCr().Name))
 	}
-	return r.handleReturn(req, b, log, "", nil)
+	res, err := r.handleReturn(req, b, log, "", nil)
+	if err == nil && opsRunning {
+		res.RequeueAfter = 5 * time.Second
+	}
+	return res, err
 
 }
 
@@ -265,6 +282,22 @@ func (r *MongoDBReconciler) checkRestart(cr *middlewarev1alpha1.MongoDB, m mode.
 		}()
 	}
 
+	// 除资源外的pod模板变更(调度、exporter、sidecar等)同样需要重启，重启过程中继续重启流程
+	if !f {
+		drift, err := b.Base.CheckTemplateDrift()
+		if err != nil {
+			return err, stateNeedReconciling
+		}
+		inProcess := cr.Status.RestartState != middlewarev1alpha1.RestartStateNotInProcess && cr.Status.RestartState != ""
+		if drift || inProcess {
+			if !inProcess {
+				reqLogger.Warnf("CR %s's pod template changed", cr.Name)
+			}
+			stateNeedReconciling = true
+			f = true
+		}
+	}
+
 	if f {
 		reqLogger.Warnf("%s ready to restarting", cr.Name)
 		if cr.Status.State != middlewarev1alpha1.StateReconciling {
@@ -286,6 +319,89 @@ func (r *MongoDBReconciler) checkRestart(cr *middlewarev1alpha1.MongoDB, m mode.
 	return err, stateNeedReconciling
 }
 
+// handleOpsRequests: 按创建时间依次执行MongoDB的运维操作，同一时间只执行一个，返回是否有操作在执行
+func (r *MongoDBReconciler) handleOpsRequests(cr *middlewarev1alpha1.MongoDB, b *core.MongoBase, reqLogger *zap.SugaredLogger) bool {
+	opsList := &middlewarev1alpha1.MongoDBOpsRequestList{}
+	if err := r.Client.List(context.TODO(), opsList, client.InNamespace(cr.Namespace)); err != nil {
+		reqLogger.Errorf("list ops request error: %v", err)
+		return false
+	}
+	var ops *middlewarev1alpha1.MongoDBOpsRequest
+	for i := range opsList.Items {
+		item := &opsList.Items[i]
+		if item.Spec.MongoDBRef != cr.Name || item.Status.IsFinished() {
+			continue
+		}
+		if ops == nil || item.CreationTimestamp.Before(&ops.CreationTimestamp) {
+			ops = item
+		}
+	}
+	if ops == nil {
+		return false
+	}
+
+	var (
+		done bool
+		err  error
+	)
+	starting := ops.Status.Phase == "" || ops.Status.Phase == middlewarev1alpha1.OpsPhasePending
+	if starting {
+		reqLogger.Infof("start ops request %s, type: %s", ops.Name, ops.Spec.Type)
+		now := metav1.Now()
+		ops.Status.Phase = middlewarev1alpha1.OpsPhaseRunning
+		ops.Status.Message = ""
+		if ops.Status.StartTime == nil {
+			ops.Status.StartTime = &now
+		}
+		done, err = b.Base.StartOps(ops)
+	} else {
+		done, err = b.Base.CheckOps(ops)
+	}
+
+	if err != nil && !errors2.Is(err, util.ErrOpsFailed) {
+		// 临时错误，下次调和继续，启动失败时下次重新启动
+		reqLogger.Warnf("ops request %s error: %v", ops.Name, err)
+	}
+	advanceOpsPhase(ops, starting, done, err)
+	if ops.Status.IsFinished() {
+		now := metav1.Now()
+		ops.Status.CompletionTime = &now
+		reqLogger.Infof("ops request %s finished, phase: %s", ops.Name, ops.Status.Phase)
+		if ops.Status.Phase == middlewarev1alpha1.OpsPhaseFailed {
+			r.Event.CustomWarningEvent(cr, "MongoDBOpsRequestFailed",
+				fmt.Sprintf("OpsRequest: %s, Type: %s, Message: %s", ops.Name, ops.Spec.Type, ops.Status.Message))
+		} else {
+			r.Event.CustomNormalEvent(cr, "MongoDBOpsRequestSucceeded",
+				fmt.Sprintf("OpsRequest: %s, Type: %s", ops.Name, ops.Spec.Type))
+		}
+	}
+	if e := k8s.UpdateObjectStatus(r.Client, ops); e != nil {
+		reqLogger.Errorf("update ops request %s status error: %v", ops.Name, e)
+	}
+	return !ops.Status.IsFinished()
+}
+
+// advanceOpsPhase: 根据本次执行结果推进运维操作的阶段，超时的操作置为失败
+func advanceOpsPhase(ops *middlewarev1alpha1.MongoDBOpsRequest, starting, done bool, err error) {
+	switch {
+	case errors2.Is(err, util.ErrOpsFailed):
+		ops.Status.Phase = middlewarev1alpha1.OpsPhaseFailed
+		ops.Status.Message = err.Error()
+	case err != nil:
+		ops.Status.Message = err.Error()
+		if starting {
+			ops.Status.Phase = middlewarev1alpha1.OpsPhasePending
+		}
+	case done:
+		ops.Status.Phase = middlewarev1alpha1.OpsPhaseSucceeded
+	}
+	if !ops.Status.IsFinished() && ops.Status.StartTime != nil && ops.Spec.TimeoutSeconds > 0 &&
+		time.Since(ops.Status.StartTime.Time) > time.Duration(ops.Spec.TimeoutSeconds)*time.Second {
+		ops.Status.Phase = middlewarev1alpha1.OpsPhaseFailed
+		ops.Status.Message = fmt.Sprintf("timeout after %ds, last message: %s", ops.Spec.TimeoutSeconds, ops.Status.Message)
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *MongoDBReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.mgr = mgr
@@ -294,6 +410,18 @@ func (r *MongoDBReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&middlewarev1alpha1.MongoDB{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Pod{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&source.Kind{Type: &middlewarev1alpha1.MongoDBOpsRequest{}},
+			handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+				ops, ok := obj.(*middlewarev1alpha1.MongoDBOpsRequest)
+				if !ok || ops.Status.IsFinished() {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{
+					Namespace: ops.Namespace,
+					Name:      ops.Spec.MongoDBRef,
+				}}}
+			})).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
 		Complete(r)
 }
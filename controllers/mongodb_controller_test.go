@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+func TestAdvanceOpsPhase(t *testing.T) {
+	recent := metav1.NewTime(time.Now().Add(-10 * time.Second))
+	expired := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	tests := []struct {
+		name        string
+		startTime   *metav1.Time
+		timeout     int64
+		starting    bool
+		done        bool
+		err         error
+		wantPhase   middlewarev1alpha1.OpsPhase
+		wantMessage string
+	}{
+		{
+			name:      "started and running",
+			startTime: &recent,
+			starting:  true,
+			wantPhase: middlewarev1alpha1.OpsPhaseRunning,
+		},
+		{
+			name:        "start error returns to pending",
+			startTime:   &recent,
+			starting:    true,
+			err:         errors.New("connection refused"),
+			wantPhase:   middlewarev1alpha1.OpsPhasePending,
+			wantMessage: "connection refused",
+		},
+		{
+			name:        "check error keeps running",
+			startTime:   &recent,
+			err:         errors.New("connection refused"),
+			wantPhase:   middlewarev1alpha1.OpsPhaseRunning,
+			wantMessage: "connection refused",
+		},
+		{
+			name:        "ops failed",
+			startTime:   &recent,
+			starting:    true,
+			err:         pkgerrors.Wrap(util.ErrOpsFailed, "member is required"),
+			wantPhase:   middlewarev1alpha1.OpsPhaseFailed,
+			wantMessage: "member is required",
+		},
+		{
+			name:      "done",
+			startTime: &recent,
+			done:      true,
+			wantPhase: middlewarev1alpha1.OpsPhaseSucceeded,
+		},
+		{
+			name:      "not timed out",
+			startTime: &recent,
+			timeout:   60,
+			wantPhase: middlewarev1alpha1.OpsPhaseRunning,
+		},
+		{
+			name:        "timeout",
+			startTime:   &expired,
+			timeout:     60,
+			err:         errors.New("connection refused"),
+			wantPhase:   middlewarev1alpha1.OpsPhaseFailed,
+			wantMessage: "timeout after 60s, last message: connection refused",
+		},
+		{
+			name:      "done before timeout check",
+			startTime: &expired,
+			timeout:   60,
+			done:      true,
+			wantPhase: middlewarev1alpha1.OpsPhaseSucceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := &middlewarev1alpha1.MongoDBOpsRequest{
+				Spec: middlewarev1alpha1.MongoDBOpsRequestSpec{TimeoutSeconds: tt.timeout},
+				Status: middlewarev1alpha1.MongoDBOpsRequestStatus{
+					Phase:     middlewarev1alpha1.OpsPhaseRunning,
+					StartTime: tt.startTime,
+				},
+			}
+			advanceOpsPhase(ops, tt.starting, tt.done, tt.err)
+			if ops.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %s, want %s", ops.Status.Phase, tt.wantPhase)
+			}
+			if !strings.Contains(ops.Status.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want %q", ops.Status.Message, tt.wantMessage)
+			}
+		})
+	}
+}
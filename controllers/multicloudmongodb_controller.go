@@ -21,9 +21,7 @@ import (
 	"fmt"
 	"time"
 
-	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 
 	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
 	"github.com/fedstate/fedstate/pkg/controller/multicloudmongodb"
@@ -32,13 +30,13 @@ import (
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/event"
+	"github.com/fedstate/fedstate/pkg/fleet"
 )
 
 const (
@@ -51,6 +49,12 @@ type MultiCloudMongoDBReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Log    *zap.SugaredLogger
+	Event  event.IEvent
+	// 多集群后端，未设置时在SetupWithManager中按FleetBackend创建
+	Fleet        fleet.Backend
+	FleetBackend string
+	// direct后端保存成员集群kubeconfig的namespace
+	Namespace string
 }
 
 //+kubebuilder:rbac:groups=middleware.fedstate.io,resources=multicloudmongodbs,verbs=get;list;watch;create;update;patch;delete
@@ -59,6 +63,12 @@ type MultiCloudMongoDBReconciler struct {
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=*
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=*
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;create;update;patch;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.karmada.io,resources=clusters/proxy,verbs=get
+// +kubebuilder:rbac:groups=middleware.fedstate.io,resources=mongodbopsrequests,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=middleware.fedstate.io,resources=addresspools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=middleware.fedstate.io,resources=addresspools/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports;serviceimports,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -105,6 +115,8 @@ func (r *MultiCloudMongoDBReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	params := &multicloudmongodb.MultiCloudDBParams{
 		MultiCloudMongoDB:      cr,
 		Cli:                    r.Client,
+		Fleet:                  r.Fleet,
+		Event:                  r.Event,
 		ClusterToVIPMap:        make(map[string]string, 0),
 		SchedulerResult:        &model.SchedulerResult{},
 		Schema:                 r.Scheme,
@@ -112,6 +124,8 @@ func (r *MultiCloudMongoDBReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		ActiveCluster:          make([]string, 0),
 		Log:                    reqLogger,
 		ServiceNameWithCluster: make(map[string][]string, 0),
+		MemberAddrs:            make(map[string]map[string]string, 0),
+		HiddenHosts:            make(map[string]string, 0),
 	}
 
 	handlerChain := multicloudmongodb.BuildMultiCloudDBHandlerChain()
@@ -127,17 +141,20 @@ func (r *MultiCloudMongoDBReconciler) deleteExternalResources(cr *middlewarev1al
 	baselabel := k8s.BaseLabel(cr.Labels, cr.Name)
 	vipLabel := k8s.GenerateClusterVipLabel(cr.Labels, cr.Name)
 	initLabel := k8s.GenerateInitLabel(cr.Labels, cr.Name)
-	pp := &karmadaPolicyv1alpha1.PropagationPolicy{}
-	if err := k8s.DeleteObjByLabel(r.Client, pp, baselabel, cr.Namespace); err != nil {
-		return err
-	}
 	arbiterPPLabel := k8s.GenerateArbiterServicePPLabel(cr.Name)
-	if err := k8s.DeleteObjByLabel(r.Client, pp, arbiterPPLabel, cr.Namespace); err != nil && !errors.IsNotFound(err) {
-		return err
-	}
 	customConfigMapPPLabel := k8s.GenerateCustomConfigMapPPLabel(nil, fmt.Sprintf("%s-custom-configmap-pp", cr.Name))
-	if err := k8s.DeleteObjByLabel(r.Client, pp, customConfigMapPPLabel, cr.Namespace); err != nil && !errors.IsNotFound(err) {
-		return err
+	exportLabel := k8s.GenerateServiceExportLabel(cr.Labels, cr.Name)
+	// 先删除pp，成员集群上的资源随pp一起删除
+	for _, label := range []map[string]string{baselabel, arbiterPPLabel, customConfigMapPPLabel, exportLabel} {
+		ppList, err := r.Fleet.ListPlacements(cr.Namespace, label)
+		if err != nil {
+			return err
+		}
+		for i := range ppList.Items {
+			if err := r.Fleet.RemovePlacement(ppList.Items[i].Name, cr.Namespace); err != nil {
+				return err
+			}
+		}
 	}
 	svc := &corev1.Service{}
 	if err := k8s.DeleteObjByLabel(r.Client, svc, baselabel, cr.Namespace); err != nil {
@@ -151,14 +168,22 @@ func (r *MultiCloudMongoDBReconciler) deleteExternalResources(cr *middlewarev1al
 	if err := k8s.DeleteObjByLabel(r.Client, mongo, baselabel, cr.Namespace); err != nil {
 		return err
 	}
-	op := &karmadaPolicyv1alpha1.OverridePolicy{}
-	if err := k8s.DeleteObjByLabel(r.Client, op, baselabel, cr.Namespace); err != nil {
-		return err
+	addressLabel := k8s.GenerateMemberAddressLabel(cr.Labels, cr.Name)
+	for _, label := range []map[string]string{baselabel, vipLabel, initLabel, addressLabel} {
+		opList, err := r.Fleet.ListOverrides(cr.Namespace, label)
+		if err != nil {
+			return err
+		}
+		for i := range opList.Items {
+			if err := r.Fleet.RemoveOverride(opList.Items[i].Name, cr.Namespace); err != nil {
+				return err
+			}
+		}
 	}
-	if err := k8s.DeleteObjByLabel(r.Client, op, vipLabel, cr.Namespace); err != nil {
+	if err := k8s.DeleteObjByLabel(r.Client, svc, exportLabel, cr.Namespace); err != nil {
 		return err
 	}
-	if err := k8s.DeleteObjByLabel(r.Client, op, initLabel, cr.Namespace); err != nil {
+	if err := multicloudmongodb.ReleaseAddresses(r.Client, cr); err != nil {
 		return err
 	}
 
@@ -167,15 +192,16 @@ func (r *MultiCloudMongoDBReconciler) deleteExternalResources(cr *middlewarev1al
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MultiCloudMongoDBReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Fleet == nil {
+		backend, err := fleet.NewBackend(r.FleetBackend, mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig(), r.Namespace)
+		if err != nil {
+			return err
+		}
+		r.Fleet = backend
+	}
+	r.Event = event.NewSEvent(mgr.GetEventRecorderFor("multicloudmongodb-controller"))
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&middlewarev1alpha1.MultiCloudMongoDB{}, builder.WithPredicates(
-			predicate.NewPredicateFuncs(
-				func(object client.Object) bool {
-					if _, ok := object.GetAnnotations()["schedulerResult"]; ok {
-						return ok
-					}
-					return false
-				}))).
+		For(&middlewarev1alpha1.MultiCloudMongoDB{}).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
 		Complete(r)
 }
@@ -54,6 +54,7 @@ import (
 	//+kubebuilder:scaffold:imports
 
 	c "github.com/fedstate/fedstate/pkg/config"
+	"github.com/fedstate/fedstate/pkg/fleet"
 	"github.com/fedstate/fedstate/pkg/logi"
 	"github.com/fedstate/fedstate/pkg/metrics"
 )
@@ -98,7 +99,8 @@ func main() {
 	ctrl.SetLogger(zapr.NewLogger(logi.Log))
 
 	var restConfig *rest.Config
-	if config.EnableMultiCloudMongoDBController {
+	// direct后端运行在控制面集群上，通过Secret中的kubeconfig访问成员集群
+	if config.EnableMultiCloudMongoDBController && config.FleetBackend != fleet.BackendDirect {
 		loader := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfig}
 		loadConfig, err := loader.Load()
 		if err != nil {
@@ -155,9 +157,11 @@ func main() {
 		<-setupFinished
 		if config.EnableMultiCloudMongoDBController {
 			if err = (&controllers.MultiCloudMongoDBReconciler{
-				Client: mgr.GetClient(),
-				Scheme: mgr.GetScheme(),
-				Log:    logi.Log.With(zap.String("controller", "MultiCloudMongoDB")).Sugar(),
+				Client:       mgr.GetClient(),
+				Scheme:       mgr.GetScheme(),
+				Log:          logi.Log.With(zap.String("controller", "MultiCloudMongoDB")).Sugar(),
+				FleetBackend: config.FleetBackend,
+				Namespace:    GetOperatorNamespace(),
 			}).SetupWithManager(mgr); err != nil {
 				setupLog.Error(err, "unable to create controller", "controller", "MultiCloudMongoDB")
 				os.Exit(1)
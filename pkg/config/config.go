@@ -12,6 +12,8 @@ type Config struct {
 	MaxConcurrentReconciles           int
 	EnableMultiCloudMongoDBController bool
 	EnableMongoDBController           bool
+	// 多集群后端，karmada或direct
+	FleetBackend string
 }
 
 var Vip = viper.New()
@@ -35,6 +37,7 @@ func SetupFlag(flagset *flag.FlagSet) *Config {
 
 	flagset.BoolVar(&cfg.EnableMultiCloudMongoDBController, "enable-multi-cloud-mongodb-controller", false, "Enable multi cloud mongodb controller")
 	flagset.BoolVar(&cfg.EnableMongoDBController, "enable-mongodb-controller", false, "Enable mongodb controller")
+	flagset.StringVar(&cfg.FleetBackend, "fleet-backend", "karmada", "the multi cluster backend used by multi cloud mongodb controller, karmada or direct")
 	flagset.IntVar(&cfg.MaxConcurrentReconciles, "workers", 1, "the maximum number of concurrent Reconciles which can be run in operator")
 	return &cfg
 
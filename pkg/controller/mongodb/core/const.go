@@ -2,6 +2,9 @@ package core
 
 import (
 	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -28,6 +31,12 @@ const (
 	LabelKeyArbiter      = "arbiter"
 	LabelKeyData         = "data"
 	LabelKeyRevisionHash = "mongodb.k8s.io/revision-hash"
+	// 标识pod当前在副本集中的角色，由operator根据replSetGetStatus维护
+	LabelKeyMemberRole = "mongodb.k8s.io/member-role"
+	// sts和pod模板的hash，用于检测模板变更
+	AnnotationKeyTemplateHash = "mongodb.k8s.io/template-hash"
+	// 运维操作要求重启的时间，变化后pod模板hash变化，触发滚动重启
+	AnnotationKeyRestartedAt = "mongodb.k8s.io/restarted-at"
 
 	LabelValIndex      = "index"
 	LabelValStandalone = "standalone"
@@ -35,6 +44,8 @@ const (
 	LabelValConfigsvr  = "configsvr"
 	LabelValShardsvr   = "shardsvr"
 	LabelValMongos     = "mongos"
+	LabelValPrimary    = "primary"
+	LabelValSecondary  = "secondary"
 
 	LabelValTrue     = "true"
 	LabelValExporter = "exporter"
@@ -44,6 +55,22 @@ const (
 	ExporterContainerName  = "metrics-exporter"
 
 	HostnameTopologyKey = "kubernetes.io/hostname"
+
+	// 由operator根据replSetGetStatus设置的pod readiness gate
+	ConditionTypeMemberHealthy      corev1.PodConditionType = "mongodb.fedstate.io/member-healthy"
+	DefaultMaxReplicationLagSeconds                         = 60
+
+	// primary退出前stepDown需要的时间
+	DefaultTerminationGracePeriodSeconds = 60
+	// 预留给mongod自身关闭的时间
+	shutdownReservedSeconds = 10
+
+	// 跟随副本集角色的service后缀
+	SuffixPrimaryService     = "-primary"
+	SuffixSecondariesService = "-secondaries"
+
+	// 手动切换primary等待选举完成的时间
+	switchoverTimeout = 2 * time.Minute
 )
 
 var (
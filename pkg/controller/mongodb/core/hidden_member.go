@@ -0,0 +1,75 @@
+package core
+
+import (
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+// 过滤掉隐藏成员和延迟成员的sts
+func filterMemberSts(stsList []appsv1.StatefulSet) []appsv1.StatefulSet {
+	result := make([]appsv1.StatefulSet, 0, len(stsList))
+	for _, sts := range stsList {
+		if sts.Labels[middlewarev1alpha1.LabelKeyMemberKind] != "" {
+			continue
+		}
+		result = append(result, sts)
+	}
+	return result
+}
+
+// 根据控制面下发的service创建隐藏成员和延迟成员，service删除后从副本集中移除成员并删除sts
+func (s *base) syncHiddenMembers(dataLabels map[string]string) error {
+	serviceList, err := k8s.ListService(s.Client, s.cr.Namespace, map[string]string{LabelKeyInstance: s.cr.Name})
+	if err != nil {
+		return errors.Wrap(util.ErrObjSync, err.Error())
+	}
+
+	services := make(map[string]bool)
+	for i := range serviceList {
+		kind := serviceList[i].Labels[middlewarev1alpha1.LabelKeyMemberKind]
+		if kind == "" {
+			continue
+		}
+		services[serviceList[i].Name] = true
+		labels := k8s.MergeLabels(dataLabels, map[string]string{middlewarev1alpha1.LabelKeyMemberKind: kind})
+		if err := s.EnsureSts(s.Builder.MongoSts(serviceList[i].Name, labels,
+			staticMongoCommand.CommandReplSet(dataLabels[LabelKeyReplsetName], s.cr.Spec.CustomConfigRef))); err != nil {
+			return errors.Wrap(util.ErrObjSync, err.Error())
+		}
+	}
+
+	stsList, err := k8s.ListSts(s.Client, s.cr.Namespace, dataLabels)
+	if err != nil {
+		return errors.Wrap(util.ErrObjSync, err.Error())
+	}
+	for i := range stsList {
+		sts := stsList[i]
+		if sts.Labels[middlewarev1alpha1.LabelKeyMemberKind] == "" || services[sts.Name] {
+			continue
+		}
+		s.log.Infof("remove %s member: %s", sts.Labels[middlewarev1alpha1.LabelKeyMemberKind], sts.Name)
+		pods, err := s.ListPod(sts.Spec.Selector.MatchLabels)
+		if err != nil {
+			return errors.Wrap(util.ErrObjSync, err.Error())
+		}
+		if len(pods) == 0 {
+			if err := k8s.DeleteSts(s.Client, s.cr.Namespace, sts.Name); err != nil {
+				return errors.Wrap(util.ErrObjSync, err.Error())
+			}
+			continue
+		}
+		serverStatusRepl, err := s.GetMgoDataNodeInfo(pods[0])
+		if err != nil {
+			return errors.Wrap(util.ErrObjSync, err.Error())
+		}
+		if err := s.MongoRemoveMemberAndDeleteSts(pods[0], serverStatusRepl.Me, s.cr.Spec.MetricsExporterSpec.Enable); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+func (s *resourceBuilder) terminationGracePeriodSeconds() *int64 {
+	if period := s.podSpec().TerminationGracePeriodSeconds; period != nil {
+		return period
+	}
+	var period int64 = DefaultTerminationGracePeriodSeconds
+	return &period
+}
+
+// preStop使用clusterAdmin用户执行stepDown，从secret注入认证信息
+func (s *resourceBuilder) clusterAdminEnv() []corev1.EnvVar {
+	secretName := s.UserSecretMetaOnly(mgo.MongoClusterAdmin).Name
+	optional := true
+
+	env := make([]corev1.EnvVar, 0, 2)
+	for _, key := range []string{mgo.MongoUser, mgo.MongoPassword} {
+		env = append(env, corev1.EnvVar{
+			Name: key,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  key,
+					Optional:             &optional,
+				},
+			},
+		})
+	}
+
+	return env
+}
+
+// pod在operator重启流程之外被删除(驱逐、节点下线等)时，primary先stepDown再退出，避免不干净的选举
+func (s *resourceBuilder) mongodLifecycle() *corev1.Lifecycle {
+	wait := *s.terminationGracePeriodSeconds() - shutdownReservedSeconds
+	if wait < 1 {
+		wait = 1
+	}
+	script := fmt.Sprintf(mgo.PreStopStepDown, wait)
+
+	return &corev1.Lifecycle{
+		PreStop: &corev1.LifecycleHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{
+					"sh", "-c",
+					fmt.Sprintf(preStopWithAuth, mgo.MongoUser, mgo.MongoPassword, mgo.DbAdmin, script, mgo.MongoShell),
+				},
+			},
+		},
+	}
+}
+
+// 认证信息从环境变量写入只有当前用户可读的临时脚本，不出现在命令行参数中(printf是sh的内置命令)
+const preStopWithAuth = `umask 077
+f="${TMPDIR:-/tmp}/prestop-$$.js"
+trap 'rm -f "$f"' EXIT
+: > "$f"
+if [ -n "$%[1]s" ]; then
+  u=$(printf '%%s' "$%[1]s" | sed 's/[\\"]/\\&/g')
+  p=$(printf '%%s' "$%[2]s" | sed 's/[\\"]/\\&/g')
+  printf 'db.getSiblingDB("%[3]s").auth("%%s", "%%s");\n' "$u" "$p" > "$f"
+fi
+cat >> "$f" <<'SCRIPT'
+%[4]s
+SCRIPT
+%[5]s --quiet "$f"`
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+// 根据副本集成员的健康状态和复制延迟设置pod的member-healthy readiness gate
+func (s *base) UpdateMemberHealthyCondition(members []mgo.MemberStatus) error {
+	pods, err := s.ListPod(s.Builder.WithBaseLabel(map[string]string{
+		LabelKeyRole: LabelValReplset,
+	}))
+	if err != nil {
+		return err
+	}
+
+	statusMap := make(map[string]mgo.MemberStatus, len(members))
+	for _, m := range members {
+		statusMap[m.Host] = m
+	}
+	// 延迟成员配置的延迟不计入复制延迟
+	cm, err := k8s.GetConfigMap(s.Client, s.cr.Spec.MemberConfigRef, s.cr.Namespace)
+	if err != nil {
+		return err
+	}
+	delays := make(map[string]int64)
+	for _, m := range StaticReplSetUtil.ConfigMapToMembers(*s.cr, "", *cm) {
+		delays[m.Host] = m.SlaveDelay
+	}
+
+	for _, pod := range pods {
+		host, err := s.GetPodHost(pod)
+		if err != nil {
+			s.log.Warnf("get pod %s host err: %v", pod.Name, err)
+			continue
+		}
+		status, reason, message := s.memberHealthy(statusMap, host, delays[host])
+		if err := s.setPodCondition(pod, status, reason, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *base) memberHealthy(statusMap map[string]mgo.MemberStatus, host string, delay int64) (corev1.ConditionStatus, string, string) {
+	m, ok := statusMap[host]
+	if !ok {
+		return corev1.ConditionFalse, "NotMember", fmt.Sprintf("%s is not in replica set", host)
+	}
+	if m.Health != 1 {
+		return corev1.ConditionFalse, "Unhealthy", fmt.Sprintf("%s is unreachable", host)
+	}
+	switch m.StateStr {
+	case mgo.Primary, mgo.Arbiter:
+	case mgo.Secondary:
+		maxLag := s.cr.Spec.MaxReplicationLagSeconds
+		if maxLag <= 0 {
+			maxLag = DefaultMaxReplicationLagSeconds
+		}
+		if lag := m.LagSeconds - delay; lag > maxLag {
+			return corev1.ConditionFalse, "ReplicationLag", fmt.Sprintf("%s lags %ds behind primary", host, lag)
+		}
+	default:
+		return corev1.ConditionFalse, m.StateStr, fmt.Sprintf("%s is %s", host, m.StateStr)
+	}
+
+	return corev1.ConditionTrue, m.StateStr, ""
+}
+
+func (s *base) setPodCondition(pod *corev1.Pod, status corev1.ConditionStatus, reason, message string) error {
+	for i := range pod.Status.Conditions {
+		cond := &pod.Status.Conditions[i]
+		if cond.Type != ConditionTypeMemberHealthy {
+			continue
+		}
+		if cond.Status == status && cond.Reason == reason && cond.Message == message {
+			return nil
+		}
+		if cond.Status != status {
+			cond.LastTransitionTime = metav1.Now()
+		}
+		cond.Status = status
+		cond.Reason = reason
+		cond.Message = message
+		return k8s.UpdateObjectStatus(s.Client, pod)
+	}
+
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+		Type:               ConditionTypeMemberHealthy,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	s.log.Infof("set pod %s condition %s to %s", pod.Name, ConditionTypeMemberHealthy, status)
+	return k8s.UpdateObjectStatus(s.Client, pod)
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+func TestMemberHealthy(t *testing.T) {
+	s := &base{cr: &middlewarev1alpha1.MongoDB{}}
+	statusMap := map[string]mgo.MemberStatus{
+		"10.0.1.1:30001": {Host: "10.0.1.1:30001", StateStr: mgo.Secondary, Health: 1, LagSeconds: 3610},
+	}
+	tests := []struct {
+		name  string
+		delay int64
+		want  corev1.ConditionStatus
+	}{
+		{name: "lagging secondary", want: corev1.ConditionFalse},
+		{name: "delayed member", delay: 3600, want: corev1.ConditionTrue},
+		{name: "delayed member lagging", delay: 600, want: corev1.ConditionFalse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, reason, _ := s.memberHealthy(statusMap, "10.0.1.1:30001", tt.delay); got != tt.want {
+				t.Errorf("memberHealthy() = %s (%s), want %s", got, reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+)
+
+// 将本集群数据节点的priority修改为spec.memberPriority，priority高的成员追上oplog后会自动发起选举成为primary
+func (s *base) EnsureMemberPriority() error {
+	if s.cr.Spec.MemberPriority == nil {
+		return nil
+	}
+	// 切换primary时会临时修改priority，切换结束后annotation移除前保持切换结果，不恢复spec.memberPriority
+	if s.cr.Annotations[middlewarev1alpha1.AnnotationKeySwitchover] != "" ||
+		(s.cr.Status.Switchover != nil && s.cr.Status.Switchover.Phase == middlewarev1alpha1.SwitchoverPhaseRunning) {
+		return nil
+	}
+
+	pods, err := s.ListPod(s.Builder.WithBaseLabel(map[string]string{
+		LabelKeyRole: LabelValReplset,
+	}))
+	if err != nil {
+		return err
+	}
+	local := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		if StaticMongoInfoUtil.IsArbiter(pod) || pod.Labels[middlewarev1alpha1.LabelKeyMemberKind] != "" {
+			continue
+		}
+		host, err := s.GetPodHost(pod)
+		if err != nil {
+			s.log.Warnf("get pod %s host err: %v", pod.Name, err)
+			continue
+		}
+		local[host] = true
+	}
+
+	addrs, err := s.GetMongoAddrs(s.cr.Spec.MemberConfigRef, s.cr.Namespace)
+	if err != nil {
+		return err
+	}
+	client, err := s.MongoClient(addrs)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := client.Disconnect(context.TODO()); e != nil {
+			s.log.Errorf("fail to disconnect mongo client: %s", e)
+		}
+	}()
+
+	rsConfig, err := client.ReadConfig()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range rsConfig.Members {
+		m := &rsConfig.Members[i]
+		// 隐藏成员、仲裁节点和不投票的成员priority必须为0
+		if !local[m.Host] || m.Hidden || m.ArbiterOnly || m.Votes == 0 {
+			continue
+		}
+		if m.Priority != *s.cr.Spec.MemberPriority {
+			s.log.Infof("set member %s priority from %d to %d", m.Host, m.Priority, *s.cr.Spec.MemberPriority)
+			m.Priority = *s.cr.Spec.MemberPriority
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	rsConfig.Version++
+	return client.WriteConfig(rsConfig)
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"testing"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+)
+
+func TestEnsureMemberPriorityPaused(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		phase      middlewarev1alpha1.SwitchoverPhase
+		wantPaused bool
+	}{
+		{name: "switchover running", annotation: "10.0.1.1:30001", phase: middlewarev1alpha1.SwitchoverPhaseRunning, wantPaused: true},
+		{name: "switchover succeeded with annotation", annotation: "10.0.1.1:30001", phase: middlewarev1alpha1.SwitchoverPhaseSucceeded, wantPaused: true},
+		{name: "annotation removed", phase: middlewarev1alpha1.SwitchoverPhaseSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestBase()
+			priority := 3
+			s.cr.Spec.MemberPriority = &priority
+			if tt.annotation != "" {
+				s.cr.Annotations = map[string]string{middlewarev1alpha1.AnnotationKeySwitchover: tt.annotation}
+			}
+			s.cr.Status.Switchover = &middlewarev1alpha1.SwitchoverStatus{Target: "10.0.1.1:30001", Phase: tt.phase}
+
+			// 未暂停时读取hostconf，测试中不存在会返回错误
+			err := s.EnsureMemberPriority()
+			if (err == nil) != tt.wantPaused {
+				t.Errorf("EnsureMemberPriority() error = %v, wantPaused %v", err, tt.wantPaused)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+// 根据副本集成员状态维护pod上的角色label，primary/secondaries service依赖该label进行选择
+func (s *base) UpdateMemberRoleLabel(members []mgo.MemberStatus) error {
+	pods, err := s.ListPod(s.Builder.WithBaseLabel(map[string]string{
+		LabelKeyRole: LabelValReplset,
+	}))
+	if err != nil {
+		return err
+	}
+
+	roles := make(map[string]string, len(members))
+	for _, m := range members {
+		roles[m.Host] = memberRoleFromState(m.StateStr)
+	}
+
+	for _, pod := range pods {
+		// 仲裁节点不承载数据，不参与角色service
+		if StaticMongoInfoUtil.IsArbiter(pod) {
+			continue
+		}
+		host, err := s.GetPodHost(pod)
+		if err != nil {
+			s.log.Warnf("get pod %s host err: %v", pod.Name, err)
+			continue
+		}
+		role := roles[host]
+		// 隐藏成员和延迟成员不对客户端可见
+		if pod.Labels[middlewarev1alpha1.LabelKeyMemberKind] != "" {
+			role = ""
+		}
+		if err := s.setMemberRoleLabel(pod, role); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *base) setMemberRoleLabel(pod *corev1.Pod, role string) error {
+	if pod.Labels[LabelKeyMemberRole] == role {
+		return nil
+	}
+
+	if role == "" {
+		if _, ok := pod.Labels[LabelKeyMemberRole]; !ok {
+			return nil
+		}
+		delete(pod.Labels, LabelKeyMemberRole)
+	} else {
+		pod.Labels = k8s.MergeLabels(pod.Labels, map[string]string{
+			LabelKeyMemberRole: role,
+		})
+	}
+	s.log.Infof("update pod %s member role label to %q", pod.Name, role)
+
+	return k8s.UpdateObject(s.Client, pod)
+}
+
+// 只有PRIMARY和SECONDARY状态的成员可以对外提供服务
+func memberRoleFromState(state string) string {
+	switch state {
+	case mgo.Primary, mgo.Secondary:
+		return strings.ToLower(state)
+	default:
+		return ""
+	}
+}
+
+// 创建只选择primary节点和只选择secondary节点的service
+func (s *base) EnsureMemberRoleServices() error {
+	for _, role := range []string{LabelValPrimary, LabelValSecondary} {
+		if err := s.EnsureService(s.BuildMemberRoleService(role)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
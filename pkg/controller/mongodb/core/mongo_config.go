@@ -40,6 +40,10 @@ func (s *base) ReplSetInit(pods []*corev1.Pod, cm *corev1.ConfigMap) error {
 
 	members := StaticReplSetUtil.ConfigMapToMembers(*s.cr, rsName, *cm)
 	s.log.Infof("members: %v", members)
+	// 成员地址由成员集群上报时，hostconf可能还未生成
+	if len(members) == 0 {
+		return errors2.Wrap(util.ErrWaitRequeue, "no member in hostconf, wait")
+	}
 	membersJson, err := json.Marshal(members)
 	if err != nil {
 		return errors2.Wrap(err, "json marshal err")
@@ -260,13 +264,13 @@ func (s *base) MongoEnsureMemberConfig(pod *corev1.Pod) error {
 		return err
 
 	}
-	nodePort, err := s.GetServiceNodePort(pod)
+	host, err := s.GetPodHost(pod)
 	if err != nil {
-		s.log.Errorf("get service nodePort failed, err: %v", err)
+		s.log.Errorf("get pod host failed, err: %v", err)
 		return err
 	}
 	// 确定唯一的member
-	member := StaticReplSetUtil.ConfigMapToMembersByNodePort(*s.cr, rsName, *cm, int(nodePort))
+	member := StaticReplSetUtil.ConfigMapToMembersByHost(*s.cr, rsName, *cm, host)
 	rsConfig, err := client.ReadConfig()
 	if err != nil {
 		return err
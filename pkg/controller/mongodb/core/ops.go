@@ -0,0 +1,527 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+// 开始执行运维操作，返回true表示操作已完成，返回util.ErrOpsFailed表示操作无法执行
+func (s *base) StartOps(ops *middlewarev1alpha1.MongoDBOpsRequest) (bool, error) {
+	switch ops.Spec.Type {
+	case middlewarev1alpha1.OpsTypeRestart:
+		return false, s.requestRestart()
+	case middlewarev1alpha1.OpsTypeStepDown:
+		return true, s.opsStepDown(ops)
+	case middlewarev1alpha1.OpsTypeResync:
+		return false, s.resyncMember(ops)
+	case middlewarev1alpha1.OpsTypeCompact:
+		return false, s.compactMember(ops)
+	case middlewarev1alpha1.OpsTypeRepair:
+		return false, s.repairMember(ops)
+	case middlewarev1alpha1.OpsTypeReconfigure:
+		return true, s.forceReconfig(ops)
+	case middlewarev1alpha1.OpsTypeRotateCredentials:
+		return false, s.rotateCredentials(ops)
+	}
+	return false, errors.Wrapf(util.ErrOpsFailed, "unknown ops type %s", ops.Spec.Type)
+}
+
+// 检查执行中的运维操作是否完成
+func (s *base) CheckOps(ops *middlewarev1alpha1.MongoDBOpsRequest) (bool, error) {
+	switch ops.Spec.Type {
+	case middlewarev1alpha1.OpsTypeRestart, middlewarev1alpha1.OpsTypeRotateCredentials:
+		return s.checkRestartFinished()
+	case middlewarev1alpha1.OpsTypeResync:
+		return s.checkResyncFinished(ops)
+	case middlewarev1alpha1.OpsTypeCompact:
+		return s.checkCompactFinished(ops)
+	case middlewarev1alpha1.OpsTypeRepair:
+		return s.checkRepairFinished(ops)
+	}
+	return true, nil
+}
+
+// 修改重启时间后pod模板hash变化，由重启流程完成滚动重启
+func (s *base) requestRestart() error {
+	s.cr.Status.CurrentInfo.RestartedAt = time.Now().Format(time.RFC3339)
+	return s.WriteStatus()
+}
+
+func (s *base) checkRestartFinished() (bool, error) {
+	if state := s.cr.Status.RestartState; state != "" && state != middlewarev1alpha1.RestartStateNotInProcess {
+		return false, nil
+	}
+	drift, err := s.CheckTemplateDrift()
+	if err != nil {
+		return false, err
+	}
+	return !drift, nil
+}
+
+func (s *base) opsStepDown(ops *middlewarev1alpha1.MongoDBOpsRequest) error {
+	addrs, err := s.GetMongoAddrs(s.cr.Spec.MemberConfigRef, s.cr.Namespace)
+	if err != nil {
+		return err
+	}
+	client, err := s.MongoClient(addrs)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := client.Disconnect(context.TODO()); e != nil {
+			s.log.Errorf("fail to disconnect mongo client: %s", e)
+		}
+	}()
+
+	ops.Status.Message = "primary stepped down"
+	return client.StepDown()
+}
+
+// 本集群的数据节点pod，key为成员地址
+func (s *base) localMemberPods() (map[string]*corev1.Pod, error) {
+	pods, err := s.ListPod(s.Builder.WithBaseLabel(map[string]string{
+		LabelKeyRole: LabelValReplset,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*corev1.Pod, len(pods))
+	for _, pod := range pods {
+		if StaticMongoInfoUtil.IsArbiter(pod) {
+			continue
+		}
+		host, err := s.GetPodHost(pod)
+		if err != nil {
+			s.log.Warnf("get pod %s host err: %v", pod.Name, err)
+			continue
+		}
+		result[host] = pod
+	}
+	return result, nil
+}
+
+func (s *base) opsMemberPod(ops *middlewarev1alpha1.MongoDBOpsRequest) (*corev1.Pod, error) {
+	if ops.Spec.Member == "" {
+		return nil, errors.Wrapf(util.ErrOpsFailed, "member is required for %s", ops.Spec.Type)
+	}
+	pods, err := s.localMemberPods()
+	if err != nil {
+		return nil, err
+	}
+	pod, ok := pods[ops.Spec.Member]
+	if !ok {
+		return nil, errors.Wrapf(util.ErrOpsFailed, "member %s is not in this cluster", ops.Spec.Member)
+	}
+	return pod, nil
+}
+
+// 成员pod的数据盘，由sts的volumeClaimTemplates生成
+func (s *base) memberPVCName(pod *corev1.Pod) string {
+	return fmt.Sprintf("%s-replset-%s", s.cr.Name, pod.Name)
+}
+
+// 删除成员的pvc和pod，重建后从其他成员全量同步数据
+func (s *base) resyncMember(ops *middlewarev1alpha1.MongoDBOpsRequest) error {
+	pod, err := s.opsMemberPod(ops)
+	if err != nil {
+		return err
+	}
+	primary, err := s.GetPrimaryPod()
+	if err != nil {
+		return err
+	}
+	if primary == ops.Spec.Member {
+		return errors.Wrapf(util.ErrOpsFailed, "member %s is primary, step down first", ops.Spec.Member)
+	}
+
+	s.log.Infof("resync member %s, delete pod %s and its pvc", ops.Spec.Member, pod.Name)
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.memberPVCName(pod),
+			Namespace: s.cr.Namespace,
+		},
+	}
+	if err := k8s.DeleteObj(s.Client, pvc); err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	if err := k8s.DeleteObj(s.Client, pod); err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	ops.Status.Message = fmt.Sprintf("pod %s deleted, wait initial sync", pod.Name)
+	return nil
+}
+
+func (s *base) checkResyncFinished(ops *middlewarev1alpha1.MongoDBOpsRequest) (bool, error) {
+	pods, err := s.localMemberPods()
+	if err != nil {
+		return false, err
+	}
+	pod, ok := pods[ops.Spec.Member]
+	if !ok || ops.Status.StartTime == nil || pod.CreationTimestamp.Before(ops.Status.StartTime) {
+		return false, nil
+	}
+	// pvc删除完成前pod已被重建时，pod无法调度，需要再次删除pod以重建pvc
+	if pod.Status.Phase == corev1.PodPending {
+		pvc := &corev1.PersistentVolumeClaim{}
+		pvcName := s.memberPVCName(pod)
+		ok, err := k8s.IsExistsByName(s.Client, pvcName, s.cr.Namespace, pvc)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			s.log.Infof("pvc %s not found, recreate pod %s", pvcName, pod.Name)
+			return false, k8s.DeleteObj(s.Client, pod)
+		}
+		return false, nil
+	}
+
+	members, err := s.GetMgoReplSetStatus()
+	if err != nil {
+		return false, err
+	}
+	for _, m := range members {
+		if m.Host == ops.Spec.Member && m.StateStr == mgo.Secondary {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// 使用root用户连接单个成员，compact和validate需要dbAdmin权限
+func (s *base) memberRootClient(host string) (*mgo.Client, error) {
+	rootSecret := &corev1.Secret{}
+	if ok, err := k8s.IsExists(s.Client, s.Builder.UserSecretMetaOnly(mgo.MongoRoot), rootSecret); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("secret missing")
+	}
+	user, password := StaticSecretUtil.GetAuthInfo(rootSecret)
+	return mgo.Dial([]string{host}, user, password, true)
+}
+
+// 遍历成员上需要处理的集合
+func (s *base) forEachCollection(ctx context.Context, client *mgo.Client, database string, fn func(db, coll string) error) error {
+	dbs := []string{database}
+	if database == "" {
+		names, err := client.ListDatabaseNames()
+		if err != nil {
+			return err
+		}
+		dbs = names
+	}
+	for _, db := range dbs {
+		colls, err := client.ListCollectionNames(db)
+		if err != nil {
+			return err
+		}
+		for _, coll := range colls {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(db, coll); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 在后台连接成员执行，执行结束后断开连接
+func (s *base) memberJob(ops *middlewarev1alpha1.MongoDBOpsRequest, fn func(ctx context.Context, client *mgo.Client) (string, []string, error)) opsJobFunc {
+	return func(ctx context.Context) (string, []string, error) {
+		client, err := s.memberRootClient(ops.Spec.Member)
+		if err != nil {
+			return "", nil, err
+		}
+		defer func() {
+			if e := client.Disconnect(context.TODO()); e != nil {
+				s.log.Errorf("fail to disconnect mongo client: %s", e)
+			}
+		}()
+		return fn(ctx, client)
+	}
+}
+
+func (s *base) compactJob(ops *middlewarev1alpha1.MongoDBOpsRequest) opsJobFunc {
+	return s.memberJob(ops, func(ctx context.Context, client *mgo.Client) (string, []string, error) {
+		count := 0
+		if err := s.forEachCollection(ctx, client, ops.Spec.Database, func(db, coll string) error {
+			s.log.Infof("compact %s.%s on %s", db, coll, ops.Spec.Member)
+			count++
+			return client.Compact(ctx, db, coll)
+		}); err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%d collections compacted", count), nil, nil
+	})
+}
+
+// 返回损坏的集合
+func (s *base) validateJob(ops *middlewarev1alpha1.MongoDBOpsRequest) opsJobFunc {
+	return s.memberJob(ops, func(ctx context.Context, client *mgo.Client) (string, []string, error) {
+		invalid := make([]string, 0)
+		if err := s.forEachCollection(ctx, client, ops.Spec.Database, func(db, coll string) error {
+			valid, err := client.Validate(ctx, db, coll)
+			if err != nil {
+				return err
+			}
+			if !valid {
+				invalid = append(invalid, db+"."+coll)
+			}
+			return nil
+		}); err != nil {
+			return "", nil, err
+		}
+		if len(invalid) == 0 {
+			return "all collections are valid", nil, nil
+		}
+		return fmt.Sprintf("invalid collections %s, resync member", strings.Join(invalid, ",")), invalid, nil
+	})
+}
+
+func (s *base) compactMember(ops *middlewarev1alpha1.MongoDBOpsRequest) error {
+	if _, err := s.opsMemberPod(ops); err != nil {
+		return err
+	}
+	s.startOpsJob(ops, s.compactJob(ops))
+	ops.Status.Message = "compacting collections"
+	return nil
+}
+
+func (s *base) checkCompactFinished(ops *middlewarev1alpha1.MongoDBOpsRequest) (bool, error) {
+	job := s.pollOpsJob(ops, s.compactJob(ops))
+	if job == nil {
+		return false, nil
+	}
+	if job.err != nil {
+		// 下次检查时重新执行，已整理的集合再次执行很快
+		return false, job.err
+	}
+	ops.Status.Message = job.message
+	return true, nil
+}
+
+// 副本集成员无法在线修复，校验到集合损坏时通过重新同步修复
+func (s *base) repairMember(ops *middlewarev1alpha1.MongoDBOpsRequest) error {
+	if _, err := s.opsMemberPod(ops); err != nil {
+		return err
+	}
+	setOpsResync(ops, false)
+	s.startOpsJob(ops, s.validateJob(ops))
+	ops.Status.Message = "validating collections"
+	return nil
+}
+
+func (s *base) checkRepairFinished(ops *middlewarev1alpha1.MongoDBOpsRequest) (bool, error) {
+	if isOpsResync(ops) {
+		done, err := s.checkResyncFinished(ops)
+		if done {
+			setOpsResync(ops, false)
+		}
+		return done, err
+	}
+
+	job := s.pollOpsJob(ops, s.validateJob(ops))
+	if job == nil {
+		return false, nil
+	}
+	if job.err != nil {
+		return false, job.err
+	}
+	ops.Status.Message = job.message
+	if len(job.result) == 0 {
+		return true, nil
+	}
+
+	s.log.Warnf("invalid collections on %s: %v", ops.Spec.Member, job.result)
+	if err := s.resyncMember(ops); err != nil {
+		return false, err
+	}
+	setOpsResync(ops, true)
+	return false, nil
+}
+
+// 副本集失去多数成员时，连接存活的成员强制修改副本集配置。存在primary或移除的成员仍然可达时操作失败，
+// 避免强制修改配置后出现两个primary
+// ref: https://www.mongodb.com/docs/manual/tutorial/reconfigure-replica-set-with-unavailable-members/
+func (s *base) forceReconfig(ops *middlewarev1alpha1.MongoDBOpsRequest) error {
+	cm, err := k8s.GetConfigMap(s.Client, s.cr.Spec.MemberConfigRef, s.cr.Namespace)
+	if err != nil {
+		return err
+	}
+	// hostconf中的成员配置，用于生成副本集配置中没有的成员
+	confMembers := make(map[string]mgo.Member)
+	for _, m := range StaticReplSetUtil.ConfigMapToMembers(*s.cr, "", *cm) {
+		confMembers[m.Host] = m
+	}
+	hosts := ops.Spec.Members
+	if len(hosts) == 0 {
+		hosts = mgo.StaticMemberUtil.MembersAddrs(StaticReplSetUtil.ConfigMapToMembers(*s.cr, "", *cm))
+	}
+	desired := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		desired[host] = true
+	}
+
+	// 在保留的成员上执行
+	for _, host := range hosts {
+		client, err := s.MongoClientWithOneNode([]string{host})
+		if err != nil {
+			s.log.Warnf("connect %s err: %v", host, err)
+			continue
+		}
+		statuses, err := client.ReplMemberStatus()
+		if err != nil {
+			s.log.Warnf("get replset status from %s err: %v", host, err)
+			_ = client.Disconnect(context.TODO())
+			continue
+		}
+		if err := checkForceReconfig(statuses, desired); err != nil {
+			if !ops.Spec.Force {
+				_ = client.Disconnect(context.TODO())
+				return errors.Wrapf(util.ErrOpsFailed, "%v, set spec.force to reconfigure anyway", err)
+			}
+			s.log.Warnf("force reconfig on %s: %v", host, err)
+		}
+		rsConfig, err := client.ReadConfig()
+		if err != nil {
+			s.log.Warnf("read config from %s err: %v", host, err)
+			_ = client.Disconnect(context.TODO())
+			continue
+		}
+
+		members := make([]mgo.Member, 0, len(hosts))
+		exist := make(map[string]bool, len(hosts))
+		maxID := 0
+		for _, m := range rsConfig.Members {
+			if m.ID > maxID {
+				maxID = m.ID
+			}
+			if desired[m.Host] {
+				members = append(members, m)
+				exist[m.Host] = true
+			}
+		}
+		for _, h := range hosts {
+			if exist[h] {
+				continue
+			}
+			m, ok := confMembers[h]
+			if !ok {
+				m = mgo.Member{Host: h, BuildIndexes: true, Votes: 1, Priority: 1}
+			}
+			maxID++
+			m.ID = maxID
+			members = append(members, m)
+		}
+		rsConfig.Members = members
+		rsConfig.Version++
+		s.log.Infof("force reconfig on %s, members: %v", host, hosts)
+		err = client.WriteConfigWithForce(rsConfig)
+		_ = client.Disconnect(context.TODO())
+		if err != nil {
+			return err
+		}
+		ops.Status.Message = fmt.Sprintf("replset reconfigured on %s with members %s", host, strings.Join(hosts, ","))
+		return nil
+	}
+
+	return errors.Wrap(util.ErrOpsFailed, "no reachable member to reconfigure")
+}
+
+// 从存活成员看到的副本集状态：没有primary，且移除的成员都不可达
+func checkForceReconfig(statuses []mgo.MemberStatus, desired map[string]bool) error {
+	for _, m := range statuses {
+		if m.StateStr == mgo.Primary {
+			return fmt.Errorf("member %s is primary", m.Host)
+		}
+		if !desired[m.Host] && m.Health == 1 {
+			return fmt.Errorf("member %s to be removed is reachable", m.Host)
+		}
+	}
+	return nil
+}
+
+// 轮换operator使用的用户密码，root用户的密码和keyfile一致，由spec.rootPassword管理
+// 多集群部署时各集群的secret需要一致，不支持轮换
+func (s *base) rotateCredentials(ops *middlewarev1alpha1.MongoDBOpsRequest) error {
+	local, err := s.localMemberPods()
+	if err != nil {
+		return err
+	}
+	addrs, err := s.GetMongoAddrs(s.cr.Spec.MemberConfigRef, s.cr.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if _, ok := local[addr]; !ok {
+			return errors.Wrapf(util.ErrOpsFailed, "member %s is not in this cluster, rotate credentials only support single cluster", addr)
+		}
+	}
+
+	rootSecret := &corev1.Secret{}
+	if ok, err := k8s.IsExists(s.Client, s.Builder.UserSecretMetaOnly(mgo.MongoRoot), rootSecret); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("secret missing")
+	}
+	user, password := StaticSecretUtil.GetAuthInfo(rootSecret)
+	client, err := mgo.Dial(addrs, user, password, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := client.Disconnect(context.TODO()); e != nil {
+			s.log.Errorf("fail to disconnect mongo client: %s", e)
+		}
+	}()
+
+	rotated := make([]string, 0)
+	for _, u := range []string{mgo.MongoClusterAdmin, mgo.MongoClusterMonitor} {
+		secret := &corev1.Secret{}
+		if ok, err := k8s.IsExists(s.Client, s.Builder.UserSecretMetaOnly(u), secret); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		if err := s.rotateUserPassword(u, secret, client.ChangeUserPassword); err != nil {
+			return err
+		}
+		rotated = append(rotated, u)
+	}
+	ops.Status.Message = fmt.Sprintf("password of %s rotated, restart members", strings.Join(rotated, ","))
+
+	// exporter和preStop通过环境变量引用secret，需要重启后生效
+	return s.requestRestart()
+}
+
+// 新密码先写入secret再修改mongo，任一步失败时secret中都保留了mongo可能使用的密码，重试时继续使用该密码
+func (s *base) rotateUserPassword(user string, secret *corev1.Secret, changePassword func(user, pw string) error) error {
+	pw, ok := secret.Data[mgo.MongoPendingPassword]
+	if !ok {
+		pw = util.GenerateKey(PasswordLen)
+		secret.Data[mgo.MongoPendingPassword] = pw
+		if err := k8s.UpdateObject(s.Client, secret); err != nil {
+			return err
+		}
+	}
+	s.log.Infof("rotate password of user %s", user)
+	if err := changePassword(user, string(pw)); err != nil {
+		return err
+	}
+	secret.Data[mgo.MongoPassword] = pw
+	delete(secret.Data, mgo.MongoPendingPassword)
+	return k8s.UpdateObject(s.Client, secret)
+}
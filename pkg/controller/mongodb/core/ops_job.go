@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+// compact、validate在大数据量时需要执行数小时，放在后台执行，reconcile中只检查结果，避免阻塞其他MongoDB的调和
+type opsJobFunc func(ctx context.Context) (message string, result []string, err error)
+
+type opsJob struct {
+	cancel   context.CancelFunc
+	finished bool
+	message  string
+	result   []string
+	err      error
+}
+
+// 后台执行的运维操作，key为ops的uid，operator重启后丢失，由CheckOps重新启动
+// resync记录校验失败后已开始重新同步的Repair操作
+var opsJobs = struct {
+	sync.Mutex
+	jobs   map[types.UID]*opsJob
+	resync map[types.UID]bool
+}{jobs: make(map[types.UID]*opsJob), resync: make(map[types.UID]bool)}
+
+// 操作的截止时间与ops的超时时间一致，超时失败后后台命令也随之取消
+func opsJobDeadline(ops *middlewarev1alpha1.MongoDBOpsRequest) time.Time {
+	start := time.Now()
+	if ops.Status.StartTime != nil {
+		start = ops.Status.StartTime.Time
+	}
+	if ops.Spec.TimeoutSeconds > 0 {
+		return start.Add(time.Duration(ops.Spec.TimeoutSeconds) * time.Second)
+	}
+	return start.Add(util.OpsJobTimeout)
+}
+
+func (s *base) startOpsJob(ops *middlewarev1alpha1.MongoDBOpsRequest, run opsJobFunc) {
+	ctx, cancel := context.WithDeadline(context.Background(), opsJobDeadline(ops))
+	job := &opsJob{cancel: cancel}
+
+	opsJobs.Lock()
+	if old, ok := opsJobs.jobs[ops.UID]; ok {
+		old.cancel()
+	}
+	opsJobs.jobs[ops.UID] = job
+	opsJobs.Unlock()
+
+	s.log.Infof("start ops request %s in background, deadline: %s", ops.Name, opsJobDeadline(ops).Format(time.RFC3339))
+	go func() {
+		defer cancel()
+		message, result, err := run(ctx)
+
+		opsJobs.Lock()
+		defer opsJobs.Unlock()
+		job.finished = true
+		job.message, job.result, job.err = message, result, err
+	}()
+}
+
+// 检查后台操作，操作结束时返回结果并移除记录；没有记录时(operator重启)重新启动操作
+func (s *base) pollOpsJob(ops *middlewarev1alpha1.MongoDBOpsRequest, run opsJobFunc) *opsJob {
+	opsJobs.Lock()
+	job, ok := opsJobs.jobs[ops.UID]
+	finished := ok && job.finished
+	if finished {
+		delete(opsJobs.jobs, ops.UID)
+	}
+	opsJobs.Unlock()
+
+	if !ok {
+		s.startOpsJob(ops, run)
+		return nil
+	}
+	if !finished {
+		return nil
+	}
+	return job
+}
+
+func setOpsResync(ops *middlewarev1alpha1.MongoDBOpsRequest, resync bool) {
+	opsJobs.Lock()
+	defer opsJobs.Unlock()
+	if resync {
+		opsJobs.resync[ops.UID] = true
+	} else {
+		delete(opsJobs.resync, ops.UID)
+	}
+}
+
+func isOpsResync(ops *middlewarev1alpha1.MongoDBOpsRequest) bool {
+	opsJobs.Lock()
+	defer opsJobs.Unlock()
+	return opsJobs.resync[ops.UID]
+}
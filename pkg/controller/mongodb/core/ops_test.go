@@ -0,0 +1,284 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+func TestCheckForceReconfig(t *testing.T) {
+	desired := map[string]bool{"10.0.2.1:30001": true, "10.0.2.1:30002": true}
+	tests := []struct {
+		name     string
+		statuses []mgo.MemberStatus
+		wantErr  bool
+	}{
+		{
+			name: "no primary and removed members unreachable",
+			statuses: []mgo.MemberStatus{
+				{Host: "10.0.1.1:30001", StateStr: "(not reachable/healthy)", Health: 0},
+				{Host: "10.0.2.1:30001", StateStr: mgo.Secondary, Health: 1},
+				{Host: "10.0.2.1:30002", StateStr: mgo.Secondary, Health: 1},
+			},
+		},
+		{
+			name: "primary alive",
+			statuses: []mgo.MemberStatus{
+				{Host: "10.0.1.1:30001", StateStr: mgo.Primary, Health: 1},
+				{Host: "10.0.2.1:30001", StateStr: mgo.Secondary, Health: 1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "removed member reachable",
+			statuses: []mgo.MemberStatus{
+				{Host: "10.0.1.1:30001", StateStr: mgo.Secondary, Health: 1},
+				{Host: "10.0.2.1:30001", StateStr: mgo.Secondary, Health: 1},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkForceReconfig(tt.statuses, desired); (err != nil) != tt.wantErr {
+				t.Errorf("checkForceReconfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// ops中按headless地址访问成员
+func newOpsTestBase(objs ...client.Object) (*base, client.Client) {
+	s, cli := newTestBase(objs...)
+	s.cr.Spec.Expose.Type = middlewarev1alpha1.ExposeTypeHeadless
+	return s, cli
+}
+
+func TestStartOps(t *testing.T) {
+	tests := []struct {
+		name       string
+		opsType    middlewarev1alpha1.OpsType
+		wantDone   bool
+		wantFailed bool
+	}{
+		{name: "unknown type", opsType: "Unknown", wantFailed: true},
+		{name: "resync without member", opsType: middlewarev1alpha1.OpsTypeResync, wantFailed: true},
+		{name: "restart", opsType: middlewarev1alpha1.OpsTypeRestart},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newOpsTestBase()
+			ops := &middlewarev1alpha1.MongoDBOpsRequest{
+				Spec: middlewarev1alpha1.MongoDBOpsRequestSpec{Type: tt.opsType},
+			}
+			done, err := s.StartOps(ops)
+			if errors.Is(err, util.ErrOpsFailed) != tt.wantFailed {
+				t.Fatalf("StartOps() error = %v, wantFailed %v", err, tt.wantFailed)
+			}
+			if !tt.wantFailed && err != nil {
+				t.Fatal(err)
+			}
+			if done != tt.wantDone {
+				t.Errorf("StartOps() done = %v, want %v", done, tt.wantDone)
+			}
+			if tt.opsType == middlewarev1alpha1.OpsTypeRestart && s.cr.Status.CurrentInfo.RestartedAt == "" {
+				t.Errorf("restartedAt not set")
+			}
+		})
+	}
+}
+
+func TestCheckOps(t *testing.T) {
+	tests := []struct {
+		name         string
+		opsType      middlewarev1alpha1.OpsType
+		restartState middlewarev1alpha1.RestartState
+		wantDone     bool
+	}{
+		{name: "restart in process", opsType: middlewarev1alpha1.OpsTypeRestart, restartState: "Restarting"},
+		{name: "restart finished", opsType: middlewarev1alpha1.OpsTypeRestart, restartState: middlewarev1alpha1.RestartStateNotInProcess, wantDone: true},
+		{name: "finished on start", opsType: middlewarev1alpha1.OpsTypeStepDown, wantDone: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newOpsTestBase()
+			s.cr.Status.RestartState = tt.restartState
+			ops := &middlewarev1alpha1.MongoDBOpsRequest{
+				Spec: middlewarev1alpha1.MongoDBOpsRequestSpec{Type: tt.opsType},
+			}
+			done, err := s.CheckOps(ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if done != tt.wantDone {
+				t.Errorf("CheckOps() done = %v, want %v", done, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestCheckResyncPendingPod(t *testing.T) {
+	start := metav1.NewTime(time.Now().Add(-time.Minute))
+	tests := []struct {
+		name          string
+		pvcName       string
+		wantPodExists bool
+	}{
+		{name: "pvc recreated", pvcName: "sample-replset-sample-replset-0-0", wantPodExists: true},
+		{name: "pvc missing", pvcName: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "sample-replset-0-0",
+					Namespace:         "default",
+					CreationTimestamp: metav1.Now(),
+					Labels: map[string]string{
+						LabelKeyInstance: "sample",
+						LabelKeyRole:     LabelValReplset,
+					},
+					OwnerReferences: []metav1.OwnerReference{{Name: "sample-replset-0"}},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodPending},
+			}
+			for k, v := range DefaultLabels {
+				pod.Labels[k] = v
+			}
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.pvcName, Namespace: "default"},
+			}
+			s, cli := newOpsTestBase(pod, pvc)
+			ops := &middlewarev1alpha1.MongoDBOpsRequest{
+				Spec: middlewarev1alpha1.MongoDBOpsRequestSpec{
+					Type:   middlewarev1alpha1.OpsTypeResync,
+					Member: k8s.ServiceDNSAddr("sample-replset-0", "default"),
+				},
+				Status: middlewarev1alpha1.MongoDBOpsRequestStatus{StartTime: &start},
+			}
+			done, err := s.CheckOps(ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if done {
+				t.Errorf("CheckOps() done with pending pod")
+			}
+			err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: pod.Name}, &corev1.Pod{})
+			if (err == nil) != tt.wantPodExists {
+				t.Errorf("pod exists = %v, want %v", err == nil, tt.wantPodExists)
+			}
+		})
+	}
+}
+
+func TestPollOpsJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		started time.Duration
+		wantErr bool
+	}{
+		{name: "finished", started: 0},
+		{name: "deadline exceeded", started: -2 * time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newOpsTestBase()
+			start := metav1.NewTime(time.Now().Add(tt.started))
+			ops := &middlewarev1alpha1.MongoDBOpsRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "compact", UID: types.UID(tt.name)},
+				Spec:       middlewarev1alpha1.MongoDBOpsRequestSpec{TimeoutSeconds: 1},
+				Status:     middlewarev1alpha1.MongoDBOpsRequestStatus{StartTime: &start},
+			}
+			runs := 0
+			run := func(ctx context.Context) (string, []string, error) {
+				runs++
+				if err := ctx.Err(); err != nil {
+					return "", nil, err
+				}
+				return "1 collections compacted", nil, nil
+			}
+
+			// 第一次检查时启动操作
+			var job *opsJob
+			for i := 0; i < 100 && job == nil; i++ {
+				job = s.pollOpsJob(ops, run)
+				time.Sleep(10 * time.Millisecond)
+			}
+			if job == nil {
+				t.Fatal("job not finished")
+			}
+			if runs != 1 {
+				t.Errorf("runs = %d, want 1", runs)
+			}
+			if (job.err != nil) != tt.wantErr {
+				t.Errorf("job err = %v, wantErr %v", job.err, tt.wantErr)
+			}
+			opsJobs.Lock()
+			_, ok := opsJobs.jobs[ops.UID]
+			opsJobs.Unlock()
+			if ok {
+				t.Errorf("finished job not removed")
+			}
+		})
+	}
+}
+
+func TestRotateUserPassword(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-clusteradmin", Namespace: "default"},
+		Data: map[string][]byte{
+			mgo.MongoUser:     []byte("clusterAdmin"),
+			mgo.MongoPassword: []byte("old"),
+		},
+	}
+	s, cli := newOpsTestBase(secret)
+	stored := func() *corev1.Secret {
+		found := &corev1.Secret{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: secret.Name}, found); err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	// 修改mongo密码失败时secret保留旧密码和待生效的新密码
+	var changed []string
+	failed := func(user, pw string) error {
+		changed = append(changed, pw)
+		return errors.New("not master")
+	}
+	if err := s.rotateUserPassword("clusterAdmin", stored(), failed); err == nil {
+		t.Fatal("rotateUserPassword() want error")
+	}
+	found := stored()
+	pending := string(found.Data[mgo.MongoPendingPassword])
+	if pending == "" || string(found.Data[mgo.MongoPassword]) != "old" || changed[0] != pending {
+		t.Fatalf("secret after failure = %v, changed %v", found.Data, changed)
+	}
+
+	// 重试时使用secret中的新密码
+	succeeded := func(user, pw string) error {
+		changed = append(changed, pw)
+		return nil
+	}
+	if err := s.rotateUserPassword("clusterAdmin", found, succeeded); err != nil {
+		t.Fatal(err)
+	}
+	found = stored()
+	if changed[1] != pending || string(found.Data[mgo.MongoPassword]) != pending {
+		t.Errorf("retry changed password to %s, secret password %s, want %s", changed[1], found.Data[mgo.MongoPassword], pending)
+	}
+	if _, ok := found.Data[mgo.MongoPendingPassword]; ok {
+		t.Errorf("pending password not removed")
+	}
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+// 创建或更新PodDisruptionBudget，保证驱逐过程中副本集投票成员始终满足多数派
+func (s *base) EnsurePodDisruptionBudget(cm *corev1.ConfigMap) error {
+	cr := s.cr
+	maxUnavailable, err := s.pdbMaxUnavailable(cm)
+	if err != nil {
+		return err
+	}
+
+	pdb := s.Builder.PodDisruptionBudget(
+		cr.Name,
+		s.Builder.WithBaseLabel(),
+		map[string]string{
+			LabelKeyInstance: cr.Name,
+			LabelKeyRole:     LabelValReplset,
+		},
+		maxUnavailable)
+
+	found := &policyv1.PodDisruptionBudget{}
+	if ok, err := k8s.IsExists(s.Client, pdb, found); err != nil {
+		return err
+	} else if !ok {
+		s.log.Infof("create pdb %s, maxUnavailable: %s", pdb.Name, pdb.Spec.MaxUnavailable.String())
+		return s.SetRefAndCreateObject(pdb)
+	}
+
+	if found.Spec.MaxUnavailable != nil && *found.Spec.MaxUnavailable == *pdb.Spec.MaxUnavailable {
+		return nil
+	}
+	s.log.Infof("update pdb %s, maxUnavailable: %s", pdb.Name, pdb.Spec.MaxUnavailable.String())
+	found.Spec.MaxUnavailable = pdb.Spec.MaxUnavailable
+	return k8s.UpdateObject(s.Client, found)
+}
+
+// 根据hostconf中的投票成员(含仲裁节点)计算允许同时不可用的成员数。
+// 多云场景下各集群的pdb相互独立，将全局允许不可用的成员数分配到各集群，各集群之和不超过全局值，
+// 保证多个集群同时驱逐时投票成员仍然满足多数派。本集群成员还未加入hostconf时只限制本集群的成员数
+func (s *base) pdbMaxUnavailable(cm *corev1.ConfigMap) (int, error) {
+	cr := s.cr
+
+	local := cr.Spec.Members
+	if cr.Spec.Arbiter {
+		local++
+	}
+
+	voting := countVotingMembers(cm.Data["datas"]) + countVotingMembers(cm.Data["arbiters"])
+	if voting < local {
+		// hostconf还未包含本集群成员
+		voting = local
+	}
+
+	maxUnavailable := voting - (voting/2 + 1)
+	budgets := clusterDisruptionBudgets(cm)
+	if len(budgets) > 1 {
+		hosts, err := s.localHosts()
+		if err != nil {
+			return 0, err
+		}
+		if cluster, ok := hostConfClusterOf(cm, hosts); ok {
+			maxUnavailable = budgets[cluster]
+		}
+	}
+	if maxUnavailable > local {
+		maxUnavailable = local
+	}
+	return maxUnavailable, nil
+}
+
+// 本集群副本集成员(含仲裁节点)的地址
+func (s *base) localHosts() (map[string]bool, error) {
+	pods, err := s.ListPod(s.Builder.WithBaseLabel(map[string]string{
+		LabelKeyRole: LabelValReplset,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		host, err := s.GetPodHost(pod)
+		if err != nil {
+			s.log.Warnf("get pod %s host err: %v", pod.Name, err)
+			continue
+		}
+		hosts[host] = true
+	}
+	return hosts, nil
+}
+
+// 投票成员所在的集群，hostconf中没有记录集群时为空
+func votingMemberClusters(cm *corev1.ConfigMap) []string {
+	clusters := make([]string, 0)
+	for _, key := range []string{"datas", "arbiters"} {
+		count := 0
+		for _, line := range strings.Split(cm.Data[key], "\n") {
+			if line == "" {
+				continue
+			}
+			if count == mgo.MaxVotingMembers {
+				break
+			}
+			count++
+			clusters = append(clusters, StaticReplSetUtil.parseMemberField(line, "cluster"))
+		}
+	}
+	return clusters
+}
+
+// 将全局允许不可用的投票成员数按各集群的投票成员数分配，余数依次分给投票成员多的集群(相同时按名称)，
+// 每个集群不超过本集群的投票成员数
+func clusterDisruptionBudgets(cm *corev1.ConfigMap) map[string]int {
+	voters := make(map[string]int)
+	voting := 0
+	for _, cluster := range votingMemberClusters(cm) {
+		voters[cluster]++
+		voting++
+	}
+	if voting == 0 {
+		return voters
+	}
+	total := voting - (voting/2 + 1)
+
+	budgets := make(map[string]int, len(voters))
+	clusters := make([]string, 0, len(voters))
+	remain := total
+	for cluster, n := range voters {
+		budgets[cluster] = total * n / voting
+		remain -= budgets[cluster]
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if voters[clusters[i]] != voters[clusters[j]] {
+			return voters[clusters[i]] > voters[clusters[j]]
+		}
+		return clusters[i] < clusters[j]
+	})
+	for _, cluster := range clusters {
+		if remain == 0 {
+			break
+		}
+		if budgets[cluster] < voters[cluster] {
+			budgets[cluster]++
+			remain--
+		}
+	}
+	return budgets
+}
+
+// 本集群成员在hostconf中记录的集群
+func hostConfClusterOf(cm *corev1.ConfigMap, hosts map[string]bool) (string, bool) {
+	for _, key := range []string{"datas", "arbiters"} {
+		for _, line := range strings.Split(cm.Data[key], "\n") {
+			if line == "" {
+				continue
+			}
+			if _, host := StaticReplSetUtil.parseMember(line); hosts[host] {
+				return StaticReplSetUtil.parseMemberField(line, "cluster"), true
+			}
+		}
+	}
+	return "", false
+}
+
+// 与ConfigMapToMembers保持一致，只有前7个成员拥有投票权
+func countVotingMembers(data string) int {
+	count := 0
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" {
+			continue
+		}
+		count++
+	}
+	if count > mgo.MaxVotingMembers {
+		count = mgo.MaxVotingMembers
+	}
+	return count
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestClusterDisruptionBudgets(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]string
+		want map[string]int
+	}{
+		{
+			name: "three clusters",
+			data: map[string]string{
+				"datas": "cluster:'c1',service:'s1',host:'10.0.1.1:30001'\ncluster:'c1',service:'s2',host:'10.0.1.2:30001'\n" +
+					"cluster:'c2',service:'s3',host:'10.0.2.1:30001'\ncluster:'c2',service:'s4',host:'10.0.2.2:30001'\n" +
+					"cluster:'c3',service:'s5',host:'10.0.3.1:30001'\ncluster:'c3',service:'s6',host:'10.0.3.2:30001'\n",
+			},
+			want: map[string]int{"c1": 1, "c2": 1, "c3": 0},
+		},
+		{
+			name: "arbiter",
+			data: map[string]string{
+				"datas": "cluster:'c1',service:'s1',host:'10.0.1.1:30001'\ncluster:'c1',service:'s2',host:'10.0.1.2:30001'\n" +
+					"cluster:'c2',service:'s3',host:'10.0.2.1:30001'\ncluster:'c2',service:'s4',host:'10.0.2.2:30001'\n",
+				"arbiters": "cluster:'c3',service:'s5',host:'10.0.3.1:30001'\n",
+			},
+			want: map[string]int{"c1": 1, "c2": 1, "c3": 0},
+		},
+		{
+			name: "uneven",
+			data: map[string]string{
+				"datas": "cluster:'c1',service:'s1',host:'10.0.1.1:30001'\ncluster:'c1',service:'s2',host:'10.0.1.2:30001'\n" +
+					"cluster:'c1',service:'s3',host:'10.0.1.3:30001'\ncluster:'c2',service:'s4',host:'10.0.2.1:30001'\n" +
+					"cluster:'c2',service:'s5',host:'10.0.2.2:30001'\n",
+			},
+			want: map[string]int{"c1": 2, "c2": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clusterDisruptionBudgets(&corev1.ConfigMap{Data: tt.data})
+			if len(got) != len(tt.want) {
+				t.Fatalf("clusterDisruptionBudgets() = %v, want %v", got, tt.want)
+			}
+			for cluster, n := range tt.want {
+				if got[cluster] != n {
+					t.Errorf("clusterDisruptionBudgets() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
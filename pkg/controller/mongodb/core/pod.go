@@ -38,7 +38,8 @@ func (s *base) ListPod(label map[string]string, filter ...podFilter) ([]*corev1.
 }
 
 func (s *base) CheckPodsReady(expectedCount int, pods []*corev1.Pod) error {
-	podsReady := StaticPodUtil.PodFilter(pods, isMongodPod, isContainerAndPodRunning, isPodReady)
+	// 只检查容器就绪，member-healthy readiness gate需要副本集初始化后才会设置
+	podsReady := StaticPodUtil.PodFilter(pods, isMongodPod, isContainerAndPodRunning, isContainersReady)
 
 	if len(podsReady) < expectedCount {
 		s.log.Debug("wait pod ready")
@@ -308,3 +308,12 @@ func isPodReady(pod *corev1.Pod) bool {
 	}
 	return false
 }
+
+func isContainersReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.ContainersReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+func (s *resourceBuilder) podSpec() middlewarev1alpha1.PodSpec {
+	if s.cr.Spec.PodSpec == nil {
+		return middlewarev1alpha1.PodSpec{}
+	}
+	return *s.cr.Spec.PodSpec
+}
+
+// 脚本作为sh的参数传入，不需要转义
+func mongoShellProbe(script string) corev1.ProbeHandler {
+	return corev1.ProbeHandler{
+		Exec: &corev1.ExecAction{
+			Command: []string{"sh", "-c", fmt.Sprintf(`exec %s --quiet --eval "$1"`, mgo.MongoShell), "sh", script},
+		},
+	}
+}
+
+// 启动探针，覆盖mongod恢复journal等较长的启动过程
+func (s *resourceBuilder) mongodStartupProbe() *corev1.Probe {
+	return mergeProbe(&corev1.Probe{
+		ProbeHandler:     mongoShellProbe(mgo.ProbePing),
+		TimeoutSeconds:   5,
+		PeriodSeconds:    10,
+		FailureThreshold: 180,
+	}, s.podSpec().StartupProbe)
+}
+
+// 存活探针，本地ping
+func (s *resourceBuilder) mongodLivenessProbe() *corev1.Probe {
+	return mergeProbe(&corev1.Probe{
+		ProbeHandler:     mongoShellProbe(mgo.ProbePing),
+		TimeoutSeconds:   5,
+		PeriodSeconds:    10,
+		FailureThreshold: 6,
+	}, s.podSpec().LivenessProbe)
+}
+
+// 就绪探针，RECOVERING、STARTUP2等状态的成员不就绪
+func (s *resourceBuilder) mongodReadinessProbe() *corev1.Probe {
+	return mergeProbe(&corev1.Probe{
+		ProbeHandler:     mongoShellProbe(mgo.ProbeReadiness),
+		TimeoutSeconds:   5,
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	}, s.podSpec().ReadinessProbe)
+}
+
+func (s *resourceBuilder) exporterLivenessProbe() *corev1.Probe {
+	return mergeProbe(&corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(DefaultMetricsPort)},
+		},
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	}, s.podSpec().ExporterLivenessProbe)
+}
+
+func (s *resourceBuilder) exporterReadinessProbe() *corev1.Probe {
+	return mergeProbe(&corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(DefaultMetricsPort)},
+		},
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	}, s.podSpec().ExporterReadinessProbe)
+}
+
+// 用户设置了检查方式时直接使用，否则使用默认检查方式并覆盖非零的参数
+func mergeProbe(def, custom *corev1.Probe) *corev1.Probe {
+	if custom == nil {
+		return def
+	}
+	if custom.Exec != nil || custom.HTTPGet != nil || custom.TCPSocket != nil || custom.GRPC != nil {
+		return custom.DeepCopy()
+	}
+
+	probe := def.DeepCopy()
+	if custom.InitialDelaySeconds != 0 {
+		probe.InitialDelaySeconds = custom.InitialDelaySeconds
+	}
+	if custom.TimeoutSeconds != 0 {
+		probe.TimeoutSeconds = custom.TimeoutSeconds
+	}
+	if custom.PeriodSeconds != 0 {
+		probe.PeriodSeconds = custom.PeriodSeconds
+	}
+	if custom.SuccessThreshold != 0 {
+		probe.SuccessThreshold = custom.SuccessThreshold
+	}
+	if custom.FailureThreshold != 0 {
+		probe.FailureThreshold = custom.FailureThreshold
+	}
+	if custom.TerminationGracePeriodSeconds != nil {
+		probe.TerminationGracePeriodSeconds = custom.TerminationGracePeriodSeconds
+	}
+	return probe
+}
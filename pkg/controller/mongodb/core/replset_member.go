@@ -2,11 +2,13 @@ package core
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 
 	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
 	"github.com/fedstate/fedstate/pkg/driver/mgo"
 	"github.com/fedstate/fedstate/pkg/logi"
 )
@@ -28,6 +30,7 @@ A replica set can have up to 50 members but only 7 voting members.
 */
 func (s *replSetUtil) ConfigMapToMembers(cr middlewarev1alpha1.MongoDB, rsName string, cm corev1.ConfigMap) []mgo.Member {
 	var members []mgo.Member
+	horizons := s.memberHorizons(cr, cm)
 
 	mongoNodes := cm.Data["datas"]
 	mongoNodesArray := strings.Split(mongoNodes, "\n")
@@ -44,12 +47,14 @@ func (s *replSetUtil) ConfigMapToMembers(cr middlewarev1alpha1.MongoDB, rsName s
 			ID:           i,
 			Host:         host,
 			BuildIndexes: true,
+			Horizons:     horizons[host],
 		}
 
 		if i < 7 {
 			member.Votes = 1
 			member.Priority = 1
 		}
+		s.setMemberKind(&member, mongoNodesArray[i])
 
 		members = append(members, member)
 	}
@@ -73,6 +78,7 @@ func (s *replSetUtil) ConfigMapToMembers(cr middlewarev1alpha1.MongoDB, rsName s
 			ID:           len(members),
 			Host:         host,
 			BuildIndexes: true,
+			Horizons:     horizons[host],
 		}
 
 		if i < 7 {
@@ -87,10 +93,10 @@ func (s *replSetUtil) ConfigMapToMembers(cr middlewarev1alpha1.MongoDB, rsName s
 	return members
 }
 
-// 通过nodePort确定member
-func (s *replSetUtil) ConfigMapToMembersByNodePort(cr middlewarev1alpha1.MongoDB, rsName string, cm corev1.ConfigMap, nodePort int) []mgo.Member {
+// 通过成员地址确定member
+func (s *replSetUtil) ConfigMapToMembersByHost(cr middlewarev1alpha1.MongoDB, rsName string, cm corev1.ConfigMap, myHost string) []mgo.Member {
 	var members []mgo.Member
-	myHost := fmt.Sprintf("%s:%d", cr.Labels[LabelKeyClusterVIP], nodePort)
+	horizons := s.memberHorizons(cr, cm)
 	mongoNodes := cm.Data["datas"]
 	mongoNodesArray := strings.Split(mongoNodes, "\n")
 	for i := 0; i < len(mongoNodesArray); i++ {
@@ -107,12 +113,14 @@ func (s *replSetUtil) ConfigMapToMembersByNodePort(cr middlewarev1alpha1.MongoDB
 				// ID:           id,
 				Host:         host,
 				BuildIndexes: true,
+				Horizons:     horizons[host],
 			}
 
 			if i < 7 {
 				member.Votes = 1
 				member.Priority = 1
 			}
+			s.setMemberKind(&member, mongoNodesArray[i])
 
 			members = append(members, member)
 			break
@@ -143,6 +151,7 @@ func (s *replSetUtil) ConfigMapToMembersByNodePort(cr middlewarev1alpha1.MongoDB
 				// ID:           id,
 				Host:         host,
 				BuildIndexes: true,
+				Horizons:     horizons[host],
 			}
 
 			if i < 7 {
@@ -181,6 +190,65 @@ func (s *replSetUtil) ConfigMapToArbiterMember(cm corev1.ConfigMap) (int, string
 	return id, host
 }
 
+// 计算每个成员的split horizon，internal为成员service在集群内的dns地址，external为成员地址
+// 多个集群存在同名service时internal地址不唯一，此时不配置horizon
+func (s *replSetUtil) memberHorizons(cr middlewarev1alpha1.MongoDB, cm corev1.ConfigMap) map[string]map[string]string {
+	if !cr.Spec.Expose.SplitHorizon || cr.Spec.Expose.IsClusterLocal() {
+		return nil
+	}
+
+	horizons := make(map[string]map[string]string)
+	internalHosts := make(map[string]bool)
+	for _, key := range []string{"datas", "arbiters"} {
+		for _, line := range strings.Split(cm.Data[key], "\n") {
+			if line == "" {
+				continue
+			}
+			_, host := s.parseMember(line)
+			service := s.parseMemberField(line, "service")
+			if service == "" {
+				replSetUtilLog.Warnf("member %s has no service in hostconf, skip split horizon", host)
+				return nil
+			}
+			internal := k8s.ServiceDNSAddr(service, cr.Namespace)
+			if internalHosts[internal] {
+				replSetUtilLog.Warnf("internal horizon %s is not unique, skip split horizon", internal)
+				return nil
+			}
+			internalHosts[internal] = true
+			horizons[host] = map[string]string{
+				mgo.HorizonInternal: internal,
+				mgo.HorizonExternal: host,
+			}
+		}
+	}
+
+	return horizons
+}
+
+// 隐藏成员和延迟成员不能成为primary，也不对客户端可见，且不参与投票，多数派只由数据成员和仲裁节点构成
+func (s *replSetUtil) setMemberKind(member *mgo.Member, line string) {
+	switch s.parseMemberField(line, "kind") {
+	case middlewarev1alpha1.MemberKindHidden:
+		member.Hidden = true
+		member.Priority = 0
+		member.Votes = 0
+	case middlewarev1alpha1.MemberKindDelayed:
+		member.Hidden = true
+		member.Priority = 0
+		member.Votes = 0
+		member.SlaveDelay, _ = strconv.ParseInt(s.parseMemberField(line, "delay"), 10, 64)
+	}
+}
+
+// cluster:'member1',service:'sample-mongodb-hidden-0',kind:'hidden',delay:'0',host:'10.29.5.103:31029'
+func (s *replSetUtil) parseMemberField(member, field string) string {
+	if !strings.Contains(member, field+":'") {
+		return ""
+	}
+	return strings.Split(strings.Split(member, field+":'")[1], "'")[0]
+}
+
 // _id:0,host:'10.29.5.103:31029'
 func (s *replSetUtil) parseMember(member string) (int, string) {
 	// id, _ := strconv.Atoi(strings.Split(strings.Split(member, "id:")[1], ",host")[0])
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+)
+
+func TestConfigMapToMembers(t *testing.T) {
+	cm := corev1.ConfigMap{Data: map[string]string{
+		"datas": "cluster:'c1',service:'sample-replset-0',host:'10.0.1.1:30001'\n" +
+			"cluster:'c1',service:'sample-hidden-0',kind:'hidden',host:'10.0.1.1:30002'\n" +
+			"cluster:'c2',service:'sample-delayed-0',kind:'delayed',delay:'3600',host:'10.0.2.1:30001'\n",
+	}}
+	tests := []struct {
+		host       string
+		wantHidden bool
+		wantVotes  int
+		wantDelay  int64
+	}{
+		{host: "10.0.1.1:30001", wantVotes: 1},
+		{host: "10.0.1.1:30002", wantHidden: true},
+		{host: "10.0.2.1:30001", wantHidden: true, wantDelay: 3600},
+	}
+
+	members := StaticReplSetUtil.ConfigMapToMembers(middlewarev1alpha1.MongoDB{}, "sample", cm)
+	if len(members) != len(tests) {
+		t.Fatalf("members = %+v, want %d", members, len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			m := members[i]
+			if m.Host != tt.host || m.Hidden != tt.wantHidden || m.Votes != tt.wantVotes || m.SlaveDelay != tt.wantDelay {
+				t.Errorf("member = %+v, want host %s hidden %v votes %d delay %d", m, tt.host, tt.wantHidden, tt.wantVotes, tt.wantDelay)
+			}
+			if m.Hidden && m.Priority != 0 {
+				t.Errorf("hidden member priority = %v, want 0", m.Priority)
+			}
+		})
+	}
+}
@@ -2,16 +2,19 @@ package core
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
 	"github.com/fedstate/fedstate/pkg/config"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
 	"github.com/fedstate/fedstate/pkg/driver/mgo"
 	"github.com/fedstate/fedstate/pkg/logi"
 	"github.com/fedstate/fedstate/pkg/util"
@@ -34,6 +37,13 @@ func (s *resourceBuilder) MongoSts(name string, labels map[string]string, comman
 		Requests: cr.Spec.Resources.Requests,
 		Limits:   cr.Spec.Resources.Limits,
 	}
+	arbiter := labels[LabelKeyArbiter] == LabelValTrue
+	if arbiter && s.arbiterSpec().Resources != nil {
+		resources = corev1.ResourceRequirements{
+			Requests: s.arbiterSpec().Resources.Requests,
+			Limits:   s.arbiterSpec().Resources.Limits,
+		}
+	}
 	labels = StaticLabelUtil.AddRevision(labels, cr)
 	labels = StaticLabelUtil.AddNodeIndex(labels, name)
 	stsObjectMeta := metav1.ObjectMeta{
@@ -58,6 +68,9 @@ func (s *resourceBuilder) MongoSts(name string, labels map[string]string, comman
 							ImagePullPolicy: cr.Spec.ImagePullPolicy,
 							Command:         command,
 							Resources:       resources,
+							StartupProbe:    s.mongodStartupProbe(),
+							LivenessProbe:   s.mongodLivenessProbe(),
+							ReadinessProbe:  s.mongodReadinessProbe(),
 						},
 					},
 				},
@@ -75,6 +88,35 @@ func (s *resourceBuilder) MongoSts(name string, labels map[string]string, comman
 		sts.Spec.Template.Spec.Tolerations = cr.Spec.PodSpec.Tolerations
 		sts.Spec.Template.Spec.TopologySpreadConstraints = cr.Spec.PodSpec.TopologySpreadConstraints
 	}
+	sts.Spec.Template.Spec.TerminationGracePeriodSeconds = s.terminationGracePeriodSeconds()
+	// 仲裁节点不会成为primary，也没有用户，不需要stepDown
+	if labels[LabelKeyArbiter] != LabelValTrue {
+		sts.Spec.Template.Spec.Containers[0].Env = s.clusterAdminEnv()
+		sts.Spec.Template.Spec.Containers[0].Lifecycle = s.mongodLifecycle()
+	}
+	if arbiter {
+		s.mergeArbiterSpec(&sts.Spec.Template.Spec)
+	} else if labels[middlewarev1alpha1.LabelKeyMemberKind] == "" {
+		// 隐藏成员和延迟成员的sts序号与数据节点无关，不按序号覆盖
+		s.mergeMemberOverrides(name, &sts.Spec.Template.Spec)
+	}
+	// pod就绪需要成员在副本集中健康
+	sts.Spec.Template.Spec.ReadinessGates = []corev1.PodReadinessGate{
+		{
+			ConditionType: ConditionTypeMemberHealthy,
+		},
+	}
+	// 使用宿主机网络时声明hostPort，避免同一节点上调度多个成员导致端口冲突
+	if cr.Spec.Expose.GetType() == middlewarev1alpha1.ExposeTypeHostNetwork {
+		sts.Spec.Template.Spec.HostNetwork = true
+		sts.Spec.Template.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+		sts.Spec.Template.Spec.Containers[0].Ports = []corev1.ContainerPort{
+			{
+				ContainerPort: DefaultPort,
+				HostPort:      DefaultPort,
+			},
+		}
+	}
 	// 当开启exporter时，部署exporter container
 	if cr.Spec.MetricsExporterSpec.Enable {
 		sts.Spec.Template.Spec.Containers = append(sts.Spec.Template.Spec.Containers, s.exporterContainer(labels[LabelKeyArbiter]))
@@ -110,8 +152,21 @@ func (s *resourceBuilder) MongoSts(name string, labels map[string]string, comman
 	}
 
 	switch {
-	case labels[LabelKeyArbiter] == LabelValTrue:
-
+	case arbiter:
+		if persistence := s.arbiterSpec().Persistence; persistence != nil && persistence.Storage != "" {
+			pvc := s.PVC(fmt.Sprintf("%s-arbiter", cr.Name), persistence.Storage)
+			if persistence.StorageClassName != "" {
+				pvc.Spec.StorageClassName = &persistence.StorageClassName
+			}
+			sts.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+				*pvc,
+			}
+
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      pvc.Name,
+				MountPath: DefaultDBPath,
+			})
+		}
 	default:
 		pvc := s.PVC(fmt.Sprintf("%s-replset", cr.Name), cr.Spec.Persistence.Storage)
 		sts.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
@@ -124,6 +179,10 @@ func (s *resourceBuilder) MongoSts(name string, labels map[string]string, comman
 		})
 	}
 
+	// 用户自定义的volume
+	volumes = append(volumes, s.podSpec().Volumes...)
+	volumeMounts = append(volumeMounts, s.podSpec().VolumeMounts...)
+
 	sts.Spec.Template.Spec.Volumes = volumes
 	for i, v := range sts.Spec.Template.Spec.Containers {
 		if v.Name == ContainerName {
@@ -131,11 +190,87 @@ func (s *resourceBuilder) MongoSts(name string, labels map[string]string, comman
 			break
 		}
 	}
+	s.mergePodSpec(&sts.Spec.Template)
+	// 运维操作触发的滚动重启
+	if restartedAt := cr.Status.CurrentInfo.RestartedAt; restartedAt != "" {
+		sts.Spec.Template.Annotations = k8s.MergeLabels(sts.Spec.Template.Annotations, map[string]string{AnnotationKeyRestartedAt: restartedAt})
+	}
+	s.setTemplateHash(sts)
 
 	return sts
 
 }
 
+// 合并用户自定义的pod模板配置，operator使用的label不会被覆盖
+func (s *resourceBuilder) mergePodSpec(template *corev1.PodTemplateSpec) {
+	podSpec := s.podSpec()
+
+	template.Labels = k8s.MergeLabels(podSpec.Labels, template.Labels)
+	if len(podSpec.Annotations) > 0 {
+		template.Annotations = k8s.MergeLabels(template.Annotations, podSpec.Annotations)
+	}
+	template.Spec.PriorityClassName = podSpec.PriorityClassName
+	template.Spec.ServiceAccountName = podSpec.ServiceAccountName
+	template.Spec.RuntimeClassName = podSpec.RuntimeClassName
+	template.Spec.InitContainers = append(template.Spec.InitContainers, podSpec.InitContainers...)
+	template.Spec.Containers = append(template.Spec.Containers, podSpec.Containers...)
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == ContainerName {
+			template.Spec.Containers[i].SecurityContext = podSpec.ContainerSecurityContext
+			break
+		}
+	}
+}
+
+func (s *resourceBuilder) arbiterSpec() middlewarev1alpha1.ArbiterSpec {
+	if s.cr.Spec.ArbiterSpec == nil {
+		return middlewarev1alpha1.ArbiterSpec{}
+	}
+	return *s.cr.Spec.ArbiterSpec
+}
+
+// 仲裁节点的调度配置覆盖podSpec中的配置
+func (s *resourceBuilder) mergeArbiterSpec(spec *corev1.PodSpec) {
+	arbiterSpec := s.arbiterSpec()
+
+	if arbiterSpec.NodeSelector != nil {
+		spec.NodeSelector = arbiterSpec.NodeSelector
+	}
+	if arbiterSpec.Tolerations != nil {
+		spec.Tolerations = arbiterSpec.Tolerations
+	}
+}
+
+// 按成员序号覆盖数据节点的资源和调度配置
+func (s *resourceBuilder) mergeMemberOverrides(name string, spec *corev1.PodSpec) {
+	ordinal, err := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	if err != nil {
+		resourceBuilderLog.Warnf("sts %s has no member ordinal, skip member overrides", name)
+		return
+	}
+
+	for _, override := range s.cr.Spec.MemberOverrides {
+		if override.Member != nil && *override.Member != ordinal {
+			continue
+		}
+		if override.Resources != nil {
+			spec.Containers[0].Resources = corev1.ResourceRequirements{
+				Requests: override.Resources.Requests,
+				Limits:   override.Resources.Limits,
+			}
+		}
+		if override.NodeSelector != nil {
+			spec.NodeSelector = override.NodeSelector
+		}
+		if override.Tolerations != nil {
+			spec.Tolerations = override.Tolerations
+		}
+		if override.Affinity != nil {
+			spec.Affinity = override.Affinity
+		}
+	}
+}
+
 func (s *resourceBuilder) exporterContainer(arbiter string) corev1.Container {
 	cr := s.cr
 	resources := corev1.ResourceRequirements{
@@ -167,7 +302,9 @@ func (s *resourceBuilder) exporterContainer(arbiter string) corev1.Container {
 				Protocol:      corev1.ProtocolTCP,
 			},
 		},
-		Resources: resources,
+		Resources:      resources,
+		LivenessProbe:  s.exporterLivenessProbe(),
+		ReadinessProbe: s.exporterReadinessProbe(),
 	}
 }
 
@@ -282,6 +419,53 @@ func (s *resourceBuilder) Service(name string, labels, selector map[string]strin
 	return svc
 }
 
+// 创建跟随副本集角色的ClusterIP service
+func (s *resourceBuilder) MemberRoleService(name string, labels, selector map[string]string) *corev1.Service {
+	cr := s.cr
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Port:       DefaultPort,
+					TargetPort: intstr.FromInt(DefaultPort),
+				},
+			},
+			Selector: selector,
+			Type:     corev1.ServiceTypeClusterIP,
+		},
+	}
+
+	return svc
+}
+
+// 限制副本集成员同时被驱逐的数量
+func (s *resourceBuilder) PodDisruptionBudget(name string, labels, selector map[string]string, maxUnavailable int) *policyv1.PodDisruptionBudget {
+	cr := s.cr
+
+	max := intstr.FromInt(maxUnavailable)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &max,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selector,
+			},
+		},
+	}
+
+	return pdb
+}
+
 func (s *resourceBuilder) MetricService(name string, label, selector map[string]string) *corev1.Service {
 	cr := s.cr
 
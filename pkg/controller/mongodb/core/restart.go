@@ -8,9 +8,12 @@ import (
 )
 
 // 删除旧版本的pod
-func (s *base) DeletePodInRestart(updateRevision string, pod *corev1.Pod) error {
-	// TODO 走不到if的逻辑
-	if pod.ObjectMeta.Labels["controller-revision-hash"] == updateRevision {
+func (s *base) DeletePodInRestart(pod *corev1.Pod) error {
+	outdated, err := s.IsPodOutdated(pod)
+	if err != nil {
+		return err
+	}
+	if !outdated {
 		s.log.Info(fmt.Sprintf("pod %s is already updated", pod.Name))
 	} else {
 		if err := s.Client.Delete(context.TODO(), pod); err != nil {
@@ -2,7 +2,9 @@ package core
 
 import (
 	"fmt"
+	"net"
 
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
 	"github.com/fedstate/fedstate/pkg/driver/k8s"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -20,6 +22,27 @@ func (s *base) BuildMetricService(stsName string) *corev1.Service {
 
 }
 
+// 创建跟随副本集角色的service，通过pod上的角色label选择成员
+func (s *base) BuildMemberRoleService(role string) *corev1.Service {
+	cr := s.cr
+
+	name := cr.Name + SuffixPrimaryService
+	if role == LabelValSecondary {
+		name = cr.Name + SuffixSecondariesService
+	}
+
+	return s.Builder.MemberRoleService(
+		name,
+		s.Builder.WithBaseLabel(map[string]string{
+			LabelKeyRole: role,
+		}),
+		map[string]string{
+			LabelKeyInstance:   cr.Name,
+			LabelKeyRole:       LabelValReplset,
+			LabelKeyMemberRole: role,
+		})
+}
+
 func (s *base) GetServiceNodePort(pod *corev1.Pod) (int32, error) {
 	cr := s.cr
 
@@ -29,3 +52,50 @@ func (s *base) GetServiceNodePort(pod *corev1.Pod) (int32, error) {
 	}
 	return svc.Spec.Ports[0].NodePort, nil
 }
+
+// 获取pod在副本集中的成员地址，由暴露方式决定
+func (s *base) GetPodHost(pod *corev1.Pod) (string, error) {
+	cr := s.cr
+
+	switch cr.Spec.Expose.GetType() {
+	case middlewarev1alpha1.ExposeTypeClusterIP, middlewarev1alpha1.ExposeTypeHeadless:
+		return k8s.ServiceDNSAddr(pod.OwnerReferences[0].Name, cr.Namespace), nil
+	case middlewarev1alpha1.ExposeTypeHostNetwork:
+		if pod.Status.HostIP == "" {
+			return "", fmt.Errorf("pod %s host ip is not assigned", pod.Name)
+		}
+		return net.JoinHostPort(pod.Status.HostIP, DefaultPortStr), nil
+	case middlewarev1alpha1.ExposeTypeLoadBalancer:
+		svc, err := k8s.GetService(s.Client, cr.Namespace, pod.OwnerReferences[0].Name)
+		if err != nil {
+			return "", err
+		}
+		addr := k8s.GetLoadBalancerAddr(svc)
+		if addr == "" {
+			return "", fmt.Errorf("service %s load balancer ingress is not assigned", svc.Name)
+		}
+		return net.JoinHostPort(addr, DefaultPortStr), nil
+	case middlewarev1alpha1.ExposeTypeMultiClusterService:
+		svc, err := k8s.GetService(s.Client, cr.Namespace, pod.OwnerReferences[0].Name)
+		if err != nil {
+			return "", err
+		}
+		// 控制面通过op写入derived service的域名
+		addr := svc.Annotations[middlewarev1alpha1.AnnotationKeyMemberAddress]
+		if addr == "" {
+			return "", fmt.Errorf("service %s multi-cluster address is not assigned", svc.Name)
+		}
+		return net.JoinHostPort(addr, DefaultPortStr), nil
+	default:
+		svc, err := k8s.GetService(s.Client, cr.Namespace, pod.OwnerReferences[0].Name)
+		if err != nil {
+			return "", err
+		}
+		// 控制面从地址池分配的地址优先于集群vip
+		addr := cr.Labels[LabelKeyClusterVIP]
+		if svc.Annotations[middlewarev1alpha1.AnnotationKeyMemberAddress] != "" {
+			addr = svc.Annotations[middlewarev1alpha1.AnnotationKeyMemberAddress]
+		}
+		return fmt.Sprintf("%s:%d", addr, svc.Spec.Ports[0].NodePort), nil
+	}
+}
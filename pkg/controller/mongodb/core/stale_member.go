@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+// 移除副本集配置中已不在hostconf且不可达的成员，集群故障重新调度后故障集群的成员由控制面从hostconf中移除
+func (s *base) RemoveStaleMembers() error {
+	health := make(map[string]int, len(s.cr.Status.ReplSet))
+	hasPrimary := false
+	for _, m := range s.cr.Status.ReplSet {
+		health[m.Host] = m.Health
+		if m.StateStr == mgo.Primary {
+			hasPrimary = true
+		}
+	}
+	// 没有primary时无法修改配置，由Reconfigure运维操作处理
+	if !hasPrimary {
+		return nil
+	}
+
+	cm, err := k8s.GetConfigMap(s.Client, s.cr.Spec.MemberConfigRef, s.cr.Namespace)
+	if err != nil {
+		return err
+	}
+	// 包含仲裁节点、隐藏成员和延迟成员
+	expected := make(map[string]bool)
+	for _, m := range StaticReplSetUtil.ConfigMapToMembers(*s.cr, "", *cm) {
+		expected[m.Host] = true
+	}
+
+	client, err := s.MongoClient(StaticConfigMapUtil.ConfigMapToAddress(*cm))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := client.Disconnect(context.TODO()); e != nil {
+			s.log.Errorf("fail to disconnect mongo client: %s", e)
+		}
+	}()
+
+	rsConfig, err := client.ReadConfig()
+	if err != nil {
+		return err
+	}
+	members, removed := retainedMembers(rsConfig.Members, expected, health)
+	if len(removed) == 0 {
+		return nil
+	}
+	s.log.Infof("remove stale members %v", removed)
+	rsConfig.Members = members
+	rsConfig.Version++
+	return client.WriteConfig(rsConfig)
+}
+
+// 保留hostconf中的成员，以及不在hostconf中但仍然可达或状态未知的成员，同时返回移除的成员地址
+func retainedMembers(members []mgo.Member, expected map[string]bool, health map[string]int) ([]mgo.Member, []string) {
+	retained := make([]mgo.Member, 0, len(members))
+	removed := make([]string, 0)
+	for _, m := range members {
+		if h, ok := health[m.Host]; !expected[m.Host] && ok && h == 0 {
+			removed = append(removed, m.Host)
+			continue
+		}
+		retained = append(retained, m)
+	}
+	return retained, removed
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+func TestRetainedMembers(t *testing.T) {
+	cm := corev1.ConfigMap{Data: map[string]string{
+		"datas":    "host:'10.0.1.1:30001'\nhost:'10.0.2.1:30001'\n",
+		"arbiters": "host:'10.0.3.1:30001'\n",
+	}}
+	expected := make(map[string]bool)
+	for _, m := range StaticReplSetUtil.ConfigMapToMembers(middlewarev1alpha1.MongoDB{}, "", cm) {
+		expected[m.Host] = true
+	}
+	members := []mgo.Member{
+		{ID: 0, Host: "10.0.1.1:30001"},
+		{ID: 1, Host: "10.0.2.1:30001"},
+		{ID: 2, Host: "10.0.3.1:30001", ArbiterOnly: true},
+		{ID: 3, Host: "10.0.4.1:30001"},
+		{ID: 4, Host: "10.0.4.1:30002"},
+	}
+	// 仲裁节点和故障集群的成员都不可达，10.0.4.1:30002仍然可达
+	health := map[string]int{
+		"10.0.1.1:30001": 1,
+		"10.0.2.1:30001": 1,
+		"10.0.3.1:30001": 0,
+		"10.0.4.1:30001": 0,
+		"10.0.4.1:30002": 1,
+	}
+
+	retained, removed := retainedMembers(members, expected, health)
+	if len(removed) != 1 || removed[0] != "10.0.4.1:30001" {
+		t.Errorf("removed = %v, want [10.0.4.1:30001]", removed)
+	}
+	hosts := mgo.StaticMemberUtil.MembersAddrs(retained)
+	if len(hosts) != 4 || hosts[2] != "10.0.3.1:30001" {
+		t.Errorf("retained = %v, want unhealthy arbiter kept", hosts)
+	}
+}
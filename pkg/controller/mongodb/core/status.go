@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"reflect"
 	"time"
 
 	"github.com/fedstate/fedstate/pkg/driver/mgo"
@@ -72,6 +73,31 @@ func (s *base) UpdateRSStatus() error {
 		return err
 	}
 	s.cr.Status.ReplSet = members
+	// 角色label和readiness gate更新失败不影响状态写入，下次调和时重试
+	if err := s.UpdateMemberRoleLabel(members); err != nil {
+		s.log.Errorf("update member role label err: %v", err)
+	}
+	if err := s.UpdateMemberHealthyCondition(members); err != nil {
+		s.log.Errorf("update member healthy condition err: %v", err)
+	}
+	return s.WriteStatus()
+}
+
+// 上报本集群成员的地址，LoadBalancer和hostNetwork模式下控制面依赖该地址生成hostconf
+func (s *base) UpdateMemberAddrs(pods []*corev1.Pod) error {
+	addrs := make(map[string]string, len(pods))
+	for _, pod := range pods {
+		host, err := s.GetPodHost(pod)
+		if err != nil {
+			s.log.Warnf("get pod %s host err: %v", pod.Name, err)
+			continue
+		}
+		addrs[pod.OwnerReferences[0].Name] = host
+	}
+	if reflect.DeepEqual(addrs, s.cr.Status.MemberAddrs) {
+		return nil
+	}
+	s.cr.Status.MemberAddrs = addrs
 	return s.WriteStatus()
 }
 
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+// 手动切换primary: 临时提高目标成员的priority，对当前primary执行stepDown，选举完成后恢复priority
+// annotation移除后清理切换状态，再次添加即可重新切换
+func (s *base) Switchover() error {
+	target := s.cr.Annotations[middlewarev1alpha1.AnnotationKeySwitchover]
+	status := s.cr.Status.Switchover
+	if target == "" {
+		if status == nil {
+			return nil
+		}
+		if status.IsFinished() {
+			s.cr.Status.Switchover = nil
+			return s.WriteStatus()
+		}
+	}
+	if status != nil && status.Target == target && status.IsFinished() {
+		return nil
+	}
+
+	addrs, err := s.GetMongoAddrs(s.cr.Spec.MemberConfigRef, s.cr.Namespace)
+	if err != nil {
+		return err
+	}
+	client, err := s.MongoClient(addrs)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := client.Disconnect(context.TODO()); e != nil {
+			s.log.Errorf("fail to disconnect mongo client: %s", e)
+		}
+	}()
+
+	// annotation被移除或修改时取消进行中的切换
+	if status != nil && status.Phase == middlewarev1alpha1.SwitchoverPhaseRunning && status.Target != target {
+		if err := s.restorePriority(client, middlewarev1alpha1.SwitchoverPhaseFailed, "switchover canceled"); err != nil {
+			return err
+		}
+		if target == "" {
+			return nil
+		}
+		return errors.Wrap(util.ErrWaitRequeue, "wait next switchover")
+	}
+
+	members, err := client.ReplMemberStatus()
+	if err != nil {
+		return err
+	}
+	var primary, targetState string
+	for _, m := range members {
+		if m.StateStr == mgo.Primary {
+			primary = m.Host
+		}
+		if m.Host == target && m.Health == 1 {
+			targetState = m.StateStr
+		}
+	}
+
+	// 切换进行中，等待选举结果
+	if status != nil && status.Target == target && status.Phase == middlewarev1alpha1.SwitchoverPhaseRunning {
+		if primary == target {
+			return s.restorePriority(client, middlewarev1alpha1.SwitchoverPhaseSucceeded,
+				fmt.Sprintf("primary switched from %s to %s", status.From, target))
+		}
+		if status.StartTime != nil && time.Since(status.StartTime.Time) > switchoverTimeout {
+			return s.restorePriority(client, middlewarev1alpha1.SwitchoverPhaseFailed,
+				fmt.Sprintf("%s not elected as primary in %s", target, switchoverTimeout))
+		}
+		return errors.Wrap(util.ErrWaitRequeue, "wait switchover election")
+	}
+
+	now := metav1.Now()
+	s.cr.Status.Switchover = &middlewarev1alpha1.SwitchoverStatus{
+		Target:    target,
+		From:      primary,
+		Phase:     middlewarev1alpha1.SwitchoverPhaseRunning,
+		StartTime: &now,
+	}
+	if primary == target {
+		return s.finishSwitchover(middlewarev1alpha1.SwitchoverPhaseSucceeded, fmt.Sprintf("%s is already primary", target))
+	}
+	if primary == "" || targetState != mgo.Secondary {
+		return s.finishSwitchover(middlewarev1alpha1.SwitchoverPhaseFailed,
+			fmt.Sprintf("%s is not a healthy secondary or no primary now", target))
+	}
+
+	rsConfig, err := client.ReadConfig()
+	if err != nil {
+		return err
+	}
+	maxPriority := 0
+	original := 0
+	for _, m := range rsConfig.Members {
+		if m.Host == target && (m.Hidden || m.ArbiterOnly || m.Votes == 0) {
+			return s.finishSwitchover(middlewarev1alpha1.SwitchoverPhaseFailed,
+				fmt.Sprintf("%s is hidden, arbiter or non-voting member", target))
+		}
+		if m.Host == target {
+			original = m.Priority
+		}
+		if m.Priority > maxPriority {
+			maxPriority = m.Priority
+		}
+	}
+
+	// 先记录原priority，修改后operator重启也能恢复
+	s.log.Infof("switchover primary from %s to %s", primary, target)
+	s.cr.Status.Switchover.OriginalPriority = &original
+	if err := s.WriteStatus(); err != nil {
+		return err
+	}
+	if _, err := client.SetMemberPriority(target, maxPriority+1); err != nil {
+		return s.restorePriority(client, middlewarev1alpha1.SwitchoverPhaseFailed,
+			fmt.Sprintf("set priority of %s: %v", target, err))
+	}
+	// 目标成员oplog未追上时stepDown会失败，priority更高的成员追上后会自动发起选举
+	if err := client.StepDown(); err != nil {
+		s.log.Warnf("step down primary %s err: %v", primary, err)
+	}
+
+	return errors.Wrap(util.ErrWaitRequeue, "wait switchover election")
+}
+
+// 恢复目标成员的priority，需要在有primary时进行
+func (s *base) restorePriority(client *mgo.Client, phase middlewarev1alpha1.SwitchoverPhase, message string) error {
+	status := s.cr.Status.Switchover
+	if status.OriginalPriority != nil {
+		if _, err := client.SetMemberPriority(status.Target, *status.OriginalPriority); err != nil {
+			return errors.Wrap(util.ErrWaitRequeue, fmt.Sprintf("restore priority of %s: %v", status.Target, err))
+		}
+	}
+	return s.finishSwitchover(phase, message)
+}
+
+func (s *base) finishSwitchover(phase middlewarev1alpha1.SwitchoverPhase, message string) error {
+	now := metav1.Now()
+	s.cr.Status.Switchover.Phase = phase
+	s.cr.Status.Switchover.Message = message
+	s.cr.Status.Switchover.CompletionTime = &now
+	s.log.Infof("switchover to %s %s: %s", s.cr.Status.Switchover.Target, phase, message)
+	return s.WriteStatus()
+}
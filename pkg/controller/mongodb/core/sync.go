@@ -69,6 +69,8 @@ func (s *base) SyncMember(selector map[string]string) error {
 	if err != nil {
 		return errors.Wrap(util.ErrObjSync, err.Error())
 	}
+	// 隐藏成员和延迟成员不计入members，单独处理
+	found = filterMemberSts(found)
 	// 满足 sts 未找到，或者 cr.spec.members 和sts的数量不一致时，更新状态为 Reconciling
 	if m != len(found) {
 		if s.cr.Status.State != middlewarev1alpha1.StateReconciling {
@@ -98,6 +100,13 @@ func (s *base) SyncMember(selector map[string]string) error {
 			if strings.HasSuffix(serviceList[i].Name, middlewarev1alpha1.ArbiterName) {
 				continue
 			}
+			// exporter、primary、secondaries等operator创建的service不对应成员
+			if serviceList[i].Labels[LabelKeyRole] != "" {
+				continue
+			}
+			if serviceList[i].Labels[middlewarev1alpha1.LabelKeyMemberKind] != "" {
+				continue
+			}
 			if err := s.EnsureSts(s.Builder.MongoSts(serviceList[i].Name, dataLabels,
 				staticMongoCommand.CommandReplSet(dataLabels[LabelKeyReplsetName],
 					s.cr.Spec.CustomConfigRef))); err != nil {
@@ -111,6 +120,9 @@ func (s *base) SyncMember(selector map[string]string) error {
 			return err
 		}
 	}
+	if err := s.syncHiddenMembers(dataLabels); err != nil {
+		return err
+	}
 	// 创建仲裁节点
 	// 下发service和cm
 	if s.cr.Spec.Arbiter {
@@ -150,6 +162,10 @@ func (s *base) SyncMember(selector map[string]string) error {
 	if len(podList) == 0 {
 		s.log.Errorf("pod selector not satisfied", selector)
 	}
+	if err := s.UpdateMemberAddrs(podList); err != nil {
+		s.log.Errorf("update member addrs err: %v", err)
+		return errors.Wrap(util.ErrObjSync, err.Error())
+	}
 	// 确保members
 	if err := s.EnsureMembers(podList); err != nil {
 		s.log.Errorf("ensure members, err: %v", err)
@@ -192,6 +208,9 @@ func (s *base) scaleDownDataNode(found []appsv1.StatefulSet, m int, selector map
 	removeMongoAddress := make([]string, 0)
 	for i := range podList {
 		pod := podList[i]
+		if pod.Labels[middlewarev1alpha1.LabelKeyMemberKind] != "" {
+			continue
+		}
 		// 每个po获取当前的host信息
 		serverStatusRepl, err := s.GetMgoDataNodeInfo(pod)
 		if err != nil {
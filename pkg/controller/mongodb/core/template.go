@@ -0,0 +1,153 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+)
+
+// 计算pod模板的hash，revision label随cr spec任意变化(如成员数)而变化，不参与计算
+func templateHash(template corev1.PodTemplateSpec) string {
+	t := template.DeepCopy()
+	delete(t.Labels, LabelKeyRevisionHash)
+	delete(t.Annotations, AnnotationKeyTemplateHash)
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		// impossible
+		resourceBuilderLog.Errorf("calculate template hash err: %v", err)
+	}
+	hf := fnv.New32()
+	_, _ = hf.Write(b)
+
+	return rand.SafeEncodeString(fmt.Sprint(hf.Sum32()))
+}
+
+// 在sts和pod模板上记录模板hash，pod上的hash与sts不一致说明pod需要重建
+func (s *resourceBuilder) setTemplateHash(sts *appsv1.StatefulSet) {
+	hash := templateHash(sts.Spec.Template)
+	sts.Annotations = k8s.MergeLabels(sts.Annotations, map[string]string{AnnotationKeyTemplateHash: hash})
+	sts.Spec.Template.Annotations = k8s.MergeLabels(sts.Spec.Template.Annotations, map[string]string{AnnotationKeyTemplateHash: hash})
+}
+
+func (s *base) listMemberSts() ([]appsv1.StatefulSet, error) {
+	return k8s.ListSts(s.Client, s.cr.Namespace, map[string]string{
+		LabelKeyInstance: s.cr.Name,
+		LabelKeyRole:     LabelValReplset,
+	})
+}
+
+// 根据当前cr生成已有sts期望的定义
+func (s *base) desiredSts(found *appsv1.StatefulSet) *appsv1.StatefulSet {
+	return s.Builder.MongoSts(found.Name, found.Labels,
+		staticMongoCommand.CommandReplSet(found.Labels[LabelKeyReplsetName], s.cr.Spec.CustomConfigRef))
+}
+
+// 检查sts模板或者pod是否和期望的模板不一致
+func (s *base) CheckTemplateDrift() (bool, error) {
+	stsList, err := s.listMemberSts()
+	if err != nil {
+		return false, err
+	}
+
+	for i := range stsList {
+		found := &stsList[i]
+		desired := s.desiredSts(found)
+		hash := desired.Annotations[AnnotationKeyTemplateHash]
+		if _, ok := found.Annotations[AnnotationKeyTemplateHash]; !ok {
+			if err := s.adoptSts(found, desired); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if found.Annotations[AnnotationKeyTemplateHash] != hash {
+			s.log.Infof("sts %s template changed", found.Name)
+			return true, nil
+		}
+
+		pods, err := s.ListPod(found.Spec.Selector.MatchLabels)
+		if err != nil {
+			return false, err
+		}
+		for _, pod := range pods {
+			if pod.Annotations[AnnotationKeyTemplateHash] != hash {
+				s.log.Infof("pod %s is outdated", pod.Name)
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// 更新sts的pod模板，sts为OnDelete策略，pod在重启流程中删除重建
+func (s *base) UpdateStsTemplate() error {
+	stsList, err := s.listMemberSts()
+	if err != nil {
+		return err
+	}
+
+	for i := range stsList {
+		found := &stsList[i]
+		desired := s.desiredSts(found)
+		hash := desired.Annotations[AnnotationKeyTemplateHash]
+		if found.Annotations[AnnotationKeyTemplateHash] == hash {
+			continue
+		}
+
+		if err := s.updateStsTemplate(found, desired); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *base) updateStsTemplate(found, desired *appsv1.StatefulSet) error {
+	hash := desired.Annotations[AnnotationKeyTemplateHash]
+	// selector不可修改，pod模板保留selector中的label
+	desired.Spec.Template.Labels = k8s.MergeLabels(desired.Spec.Template.Labels, found.Spec.Selector.MatchLabels)
+	found.Spec.Template = desired.Spec.Template
+	found.Annotations = k8s.MergeLabels(found.Annotations, map[string]string{AnnotationKeyTemplateHash: hash})
+	s.log.Infof("update sts %s template, hash: %s", found.Name, hash)
+	return k8s.UpdateObject(s.Client, found)
+}
+
+// operator升级前创建的sts没有模板hash，更新模板并在已有pod上记录hash，不触发重启，pod重建时使用新的模板
+func (s *base) adoptSts(found, desired *appsv1.StatefulSet) error {
+	if err := s.updateStsTemplate(found, desired); err != nil {
+		return err
+	}
+	hash := desired.Annotations[AnnotationKeyTemplateHash]
+	pods, err := s.ListPod(found.Spec.Selector.MatchLabels)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[AnnotationKeyTemplateHash]; ok {
+			continue
+		}
+		s.log.Infof("adopt pod %s with template hash %s", pod.Name, hash)
+		pod.Annotations = k8s.MergeLabels(pod.Annotations, map[string]string{AnnotationKeyTemplateHash: hash})
+		if err := k8s.UpdateObject(s.Client, pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pod的模板hash和所属sts不一致
+func (s *base) IsPodOutdated(pod *corev1.Pod) (bool, error) {
+	sts, err := k8s.GetSts(s.Client, pod.OwnerReferences[0].Name, s.cr.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	return pod.Annotations[AnnotationKeyTemplateHash] != sts.Annotations[AnnotationKeyTemplateHash], nil
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/logi"
+)
+
+// 使用fake client的base，cr为default/sample
+func newTestBase(objs ...client.Object) (*base, client.Client) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(middlewarev1alpha1.AddToScheme(scheme))
+
+	cr := &middlewarev1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: middlewarev1alpha1.MongoDBSpec{
+			Resources:           &middlewarev1alpha1.ResourceSetting{},
+			MetricsExporterSpec: &middlewarev1alpha1.MetricsExporterSpec{},
+			Persistence:         middlewarev1alpha1.PersistenceSpec{Storage: "1Gi"},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objs, cr)...).Build()
+	return &base{
+		Client:  cli,
+		Builder: NewResourceBuilder(cr),
+		scheme:  scheme,
+		cr:      cr,
+		log:     logi.Log.Sugar(),
+	}, cli
+}
+
+func TestCheckTemplateDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		stsHash   map[string]string
+		podHash   map[string]string
+		wantDrift bool
+	}{
+		{name: "sts created before template hash"},
+		{
+			name:      "template changed",
+			stsHash:   map[string]string{AnnotationKeyTemplateHash: "old"},
+			podHash:   map[string]string{AnnotationKeyTemplateHash: "old"},
+			wantDrift: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := map[string]string{LabelKeyInstance: "sample", LabelKeyRole: LabelValReplset, LabelKeyReplsetName: "sample-replset-0"}
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample-replset-0", Namespace: "default", Labels: selector, Annotations: tt.stsHash},
+				Spec: appsv1.StatefulSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: selector},
+					Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: selector}},
+				},
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample-replset-0-0", Namespace: "default", Labels: selector, Annotations: tt.podHash},
+			}
+			s, cli := newTestBase(sts, pod)
+
+			drift, err := s.CheckTemplateDrift()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if drift != tt.wantDrift {
+				t.Errorf("CheckTemplateDrift() = %v, want %v", drift, tt.wantDrift)
+			}
+			if tt.wantDrift {
+				return
+			}
+
+			// 接管后sts和已有pod记录期望的hash，不再认为需要重启
+			found := &appsv1.StatefulSet{}
+			if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: sts.Name}, found); err != nil {
+				t.Fatal(err)
+			}
+			hash := s.desiredSts(found).Annotations[AnnotationKeyTemplateHash]
+			if found.Annotations[AnnotationKeyTemplateHash] != hash {
+				t.Errorf("sts hash = %q, want %q", found.Annotations[AnnotationKeyTemplateHash], hash)
+			}
+			foundPod := &corev1.Pod{}
+			if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: pod.Name}, foundPod); err != nil {
+				t.Fatal(err)
+			}
+			if foundPod.Annotations[AnnotationKeyTemplateHash] != hash {
+				t.Errorf("pod hash = %q, want %q", foundPod.Annotations[AnnotationKeyTemplateHash], hash)
+			}
+			if drift, err := s.CheckTemplateDrift(); err != nil || drift {
+				t.Errorf("CheckTemplateDrift() after adoption = %v, %v", drift, err)
+			}
+		})
+	}
+}
@@ -114,6 +114,11 @@ func (s *MongoReplica) PostConfig() error {
 		return err
 	}
 
+	if err := s.Base.EnsurePodDisruptionBudget(cm); err != nil {
+		replicaSetModeLog.Errorf("ensure pdb, err: %v", err)
+		return err
+	}
+
 	// wait all pod ready
 	if err := s.Base.CheckPodsReady(s.expectedCount, pods); err != nil {
 		replicaSetModeLog.Errorf("check pod ready, err: %v", err)
@@ -138,6 +143,11 @@ func (s *MongoReplica) PostConfig() error {
 		return err
 	}
 
+	if err := s.Base.EnsureMemberRoleServices(); err != nil {
+		replicaSetModeLog.Errorf("ensure member role services, err: %v", err)
+		return err
+	}
+
 	replicaSetModeLog.Info("update rs status......")
 	if err := s.Base.UpdateRSStatus(); err != nil {
 		replicaSetModeLog.Error("PostConfig update rs status, err")
@@ -150,10 +160,25 @@ func (s *MongoReplica) PostConfig() error {
 		return err
 	}
 
+	if err := s.Base.EnsureMemberPriority(); err != nil {
+		replicaSetModeLog.Errorf("ensure member priority, err: %v", err)
+		return err
+	}
+
+	if err := s.Base.RemoveStaleMembers(); err != nil {
+		replicaSetModeLog.Errorf("remove stale members, err: %v", err)
+		return err
+	}
+
+	if err := s.Base.Switchover(); err != nil {
+		replicaSetModeLog.Errorf("switchover, err: %v", err)
+		return err
+	}
+
 	return nil
 }
 
-// replicaSet下的重启会先删除所有SENCONDARY节点, 等待其重启完成后对PRIMARY进行StepDown, 再将原PRIMARY节点进行重启
+// pod模板变更时进行重启，replicaSet下的重启会先删除所有SENCONDARY节点, 等待其重启完成后对PRIMARY进行StepDown, 再将原PRIMARY节点进行重启
 // bool为restart结束标识
 func (s *MongoReplica) Restart() (bool, error) {
 	pods, err := s.Base.ListPod(s.Base.Builder.WithBaseLabel(map[string]string{
@@ -175,29 +200,9 @@ func (s *MongoReplica) Restart() (bool, error) {
 		return false, fmt.Errorf("get primary pod err: %s", err)
 	}
 
-	for i := 0; i < len(pods); i++ {
-		pod := pods[i]
-		// 更新sts
-		stsName := pod.OwnerReferences[0].Name
-		sts, err := k8s.GetSts(s.Base.Client, stsName, s.GetCr().Namespace)
-		if err != nil {
-			return false, err
-		}
-		sts.ResourceVersion = ""
-		containers := sts.Spec.Template.Spec.Containers
-		for i := 0; i < len(containers); i++ {
-			if containers[i].Name == core.ContainerName {
-				// 修改resources
-				resources := corev1.ResourceRequirements{
-					Requests: s.GetCr().Spec.Resources.Requests,
-					Limits:   s.GetCr().Spec.Resources.Limits,
-				}
-				sts.Spec.Template.Spec.Containers[i].Resources = resources
-				if err := k8s.UpdateObject(s.Base.Client, sts); err != nil {
-					return false, err
-				}
-			}
-		}
+	// 更新sts的pod模板(资源、调度、sidecar等)
+	if err := s.Base.UpdateStsTemplate(); err != nil {
+		return false, err
 	}
 
 	switch s.GetCr().Status.RestartState {
@@ -210,7 +215,7 @@ func (s *MongoReplica) Restart() (bool, error) {
 			if !isPrimary {
 				// 可以删除pod
 				replicaSetModeLog.Infof("apply changes to secondary pod %s", po.Name)
-				if err := s.Base.DeletePodInRestart(s.GetCr().Status.CurrentRevision, po); err != nil {
+				if err := s.Base.DeletePodInRestart(po); err != nil {
 					return false, fmt.Errorf("failed to apply changes: %s", err)
 				}
 			}
@@ -223,6 +228,13 @@ func (s *MongoReplica) Restart() (bool, error) {
 				return false, err
 			}
 			if isPrimary {
+				outdated, err := s.Base.IsPodOutdated(po)
+				if err != nil {
+					return false, err
+				}
+				if !outdated {
+					continue
+				}
 				replicaSetModeLog.Infof("apply changes to primary pod %s", po.Name)
 				replicaSetModeLog.Info("doing step down...")
 				if err := s.Base.StepDown(po); err != nil {
@@ -230,7 +242,7 @@ func (s *MongoReplica) Restart() (bool, error) {
 				}
 				// 预留3s等待主从切换
 				time.Sleep(time.Second * 3)
-				if err := s.Base.DeletePodInRestart(s.GetCr().Status.CurrentRevision, po); err != nil {
+				if err := s.Base.DeletePodInRestart(po); err != nil {
 					return false, fmt.Errorf("failed to apply changes: %s", err)
 				}
 			}
@@ -245,11 +257,10 @@ func (s *MongoReplica) Restart() (bool, error) {
 
 func (s *MongoReplica) judgePodIsPrimary(pod *corev1.Pod, primary string) (bool, error) {
 	// 根据pod获取svc的nodeport和vip信息得到host信息，进行和primary比较
-	nodePort, err := s.Base.GetServiceNodePort(pod)
+	myHost, err := s.Base.GetPodHost(pod)
 	if err != nil {
 		return false, fmt.Errorf("get pod service nodeport err: %s", err)
 	}
-	myHost := fmt.Sprintf("%s:%d", s.GetCr().Labels[core.LabelKeyClusterVIP], nodePort)
 	if myHost == primary {
 		return true, nil
 	}
@@ -0,0 +1,221 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+)
+
+// 从地址池为下发到各集群的成员service分配地址，成员不再下发到集群后释放地址。
+// 分配结果记录在AddressPool的status中，通过op写入成员集群上的service
+type AddressPoolHandler struct {
+	next MultiCloudDBHandler
+}
+
+func (h *AddressPoolHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDBHandler {
+	h.next = handler
+	return handler
+}
+
+func (h *AddressPoolHandler) Handle(params *MultiCloudDBParams) error {
+	if params.MultiCloudMongoDB.Spec.Expose.AddressPool != "" {
+		params.Log.Infof("AddressPoolHandler, pool: %s", params.MultiCloudMongoDB.Spec.Expose.AddressPool)
+		if err := allocateAddresses(params); err != nil {
+			params.Log.Errorf("Allocate Addresses Failed, Err: %v", err)
+			return err
+		}
+	}
+
+	if h.next != nil {
+		return h.next.Handle(params)
+	}
+	return nil
+}
+
+func allocateAddresses(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	pool, err := k8s.GetAddressPool(params.Cli, cr.Spec.Expose.AddressPool, cr.Namespace)
+	if err != nil {
+		return err
+	}
+	placements, err := memberPlacements(params)
+	if err != nil {
+		return err
+	}
+
+	allocated, pending, changed := assignAddresses(pool, cr.Name, placements)
+	if changed {
+		// 多个实例共用地址池，冲突时下次调和重新分配
+		if err := k8s.UpdateObjectStatus(params.Cli, pool); err != nil {
+			return err
+		}
+	}
+	if len(pending) != 0 {
+		message := fmt.Sprintf("no free address in pool %s for %s", pool.Name, strings.Join(pending, ", "))
+		params.Log.Warnf("allocate addresses: %s", message)
+		params.Event.CustomWarningEvent(cr, "AddressPoolExhausted", message)
+	}
+	params.PoolAddrs = allocated
+
+	return ensureAddressOps(params, allocated)
+}
+
+// 数据成员、仲裁节点和隐藏成员的service下发到的集群，service -> clusters
+func memberPlacements(params *MultiCloudDBParams) (map[string][]string, error) {
+	cr := params.MultiCloudMongoDB
+	placements := make(map[string][]string)
+	for _, label := range []map[string]string{
+		k8s.GenerateServicePPLabel(cr.Labels, fmt.Sprintf("%s-service-pp", cr.Name)),
+		k8s.GenerateArbiterServicePPLabel(cr.Name),
+		k8s.GenerateHiddenMemberPPLabel(cr.Labels, cr.Name),
+	} {
+		ppList, err := params.Fleet.ListPlacements(cr.Namespace, label)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ppList.Items {
+			pp := ppList.Items[i]
+			if len(pp.Spec.ResourceSelectors) == 0 || pp.Spec.Placement.ClusterAffinity == nil {
+				continue
+			}
+			svc := pp.Spec.ResourceSelectors[0].Name
+			placements[svc] = append(placements[svc], pp.Spec.Placement.ClusterAffinity.ClusterNames...)
+		}
+	}
+	return placements, nil
+}
+
+// 释放owner不再使用的地址，为没有地址的成员分配集群中第一个空闲地址。
+// 返回owner的分配结果(cluster -> service -> address)、没有空闲地址的成员以及status是否变化
+func assignAddresses(pool *middlewarev1alpha1.AddressPool, owner string, placements map[string][]string) (map[string]map[string]string, []string, bool) {
+	wanted := make(map[string]bool)
+	for svc, clusters := range placements {
+		for _, cluster := range clusters {
+			wanted[cluster+"/"+svc] = true
+		}
+	}
+
+	changed := false
+	allocated := make(map[string]map[string]string)
+	used := make(map[string]bool)
+	allocations := make([]middlewarev1alpha1.AddressAllocation, 0, len(pool.Status.Allocations))
+	for _, a := range pool.Status.Allocations {
+		if a.Owner == owner {
+			if !wanted[a.Cluster+"/"+a.Service] {
+				changed = true
+				continue
+			}
+			if allocated[a.Cluster] == nil {
+				allocated[a.Cluster] = make(map[string]string)
+			}
+			allocated[a.Cluster][a.Service] = a.Address
+		}
+		used[a.Cluster+"/"+a.Address] = true
+		allocations = append(allocations, a)
+	}
+
+	members := make([]string, 0, len(wanted))
+	for member := range wanted {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	pending := make([]string, 0)
+	for _, member := range members {
+		parts := strings.SplitN(member, "/", 2)
+		cluster, svc := parts[0], parts[1]
+		if allocated[cluster][svc] != "" {
+			continue
+		}
+		address := ""
+		for _, addr := range pool.Addresses(cluster) {
+			if !used[cluster+"/"+addr] {
+				address = addr
+				break
+			}
+		}
+		if address == "" {
+			pending = append(pending, fmt.Sprintf("svc %s in cluster %s", svc, cluster))
+			continue
+		}
+		used[cluster+"/"+address] = true
+		if allocated[cluster] == nil {
+			allocated[cluster] = make(map[string]string)
+		}
+		allocated[cluster][svc] = address
+		allocations = append(allocations, middlewarev1alpha1.AddressAllocation{
+			Cluster: cluster,
+			Address: address,
+			Owner:   owner,
+			Service: svc,
+		})
+		changed = true
+	}
+	pool.Status.Allocations = allocations
+	return allocated, pending, changed
+}
+
+// 每个成员service一个op，按集群写入分配的地址，删除不再需要的op
+func ensureAddressOps(params *MultiCloudDBParams, allocated map[string]map[string]string) error {
+	cr := params.MultiCloudMongoDB
+	opLabel := k8s.GenerateMemberAddressLabel(cr.Labels, cr.Name)
+	services := make(map[string]map[string]string)
+	for cluster := range allocated {
+		for svc, addr := range allocated[cluster] {
+			if services[svc] == nil {
+				services[svc] = make(map[string]string)
+			}
+			services[svc][cluster] = addr
+		}
+	}
+
+	desired := make(map[string]bool, len(services))
+	loadBalancer := cr.Spec.Expose.GetType() == middlewarev1alpha1.ExposeTypeLoadBalancer
+	for svc, clusterToAddr := range services {
+		opName := fmt.Sprintf("%s-address", svc)
+		desired[opName] = true
+		op := karmada.GenerateServiceAddressOP(opName, cr.Namespace, opLabel, svc, clusterToAddr, loadBalancer)
+		if err := params.Fleet.Override(cr, op); err != nil {
+			return err
+		}
+	}
+
+	opList, err := params.Fleet.ListOverrides(cr.Namespace, opLabel)
+	if err != nil {
+		return err
+	}
+	for i := range opList.Items {
+		if desired[opList.Items[i].Name] {
+			continue
+		}
+		params.Log.Infof("delete member address op %s", opList.Items[i].Name)
+		if err := params.Fleet.RemoveOverride(opList.Items[i].Name, opList.Items[i].Namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 删除实例时释放地址池中分配给实例的地址
+func ReleaseAddresses(cli client.Client, cr *middlewarev1alpha1.MultiCloudMongoDB) error {
+	if cr.Spec.Expose.AddressPool == "" {
+		return nil
+	}
+	pool, err := k8s.GetAddressPool(cli, cr.Spec.Expose.AddressPool, cr.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if _, _, changed := assignAddresses(pool, cr.Name, nil); !changed {
+		return nil
+	}
+	return k8s.UpdateObjectStatus(cli, pool)
+}
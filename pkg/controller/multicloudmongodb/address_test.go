@@ -0,0 +1,55 @@
+package multicloudmongodb
+
+import (
+	"testing"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+)
+
+func TestAssignAddresses(t *testing.T) {
+	pool := &middlewarev1alpha1.AddressPool{
+		Spec: middlewarev1alpha1.AddressPoolSpec{
+			Clusters: []middlewarev1alpha1.ClusterAddresses{
+				{Cluster: "c1", Addresses: []string{"10.0.1.1", "10.0.1.2"}},
+				{Cluster: "c2", Addresses: []string{"10.0.2.1"}},
+			},
+		},
+		Status: middlewarev1alpha1.AddressPoolStatus{
+			Allocations: []middlewarev1alpha1.AddressAllocation{
+				{Cluster: "c1", Address: "10.0.1.1", Owner: "other", Service: "other-mongodb-0"},
+				{Cluster: "c2", Address: "10.0.2.1", Owner: "sample", Service: "sample-mongodb-1"},
+			},
+		},
+	}
+
+	allocated, pending, changed := assignAddresses(pool, "sample", map[string][]string{
+		"sample-mongodb-0": {"c1", "c2"},
+	})
+	if !changed {
+		t.Fatal("expected status change")
+	}
+	// c2上的地址从缩容的成员释放后重新分配
+	if allocated["c1"]["sample-mongodb-0"] != "10.0.1.2" || allocated["c2"]["sample-mongodb-0"] != "10.0.2.1" {
+		t.Fatalf("allocated = %v", allocated)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("pending = %v, want none", pending)
+	}
+	if len(pool.Status.Allocations) != 3 {
+		t.Fatalf("allocations = %v, want 3", pool.Status.Allocations)
+	}
+
+	// c1上没有空闲地址
+	_, pending, changed = assignAddresses(pool, "sample", map[string][]string{
+		"sample-mongodb-0": {"c1", "c2"},
+		"sample-mongodb-1": {"c1"},
+	})
+	if changed || len(pending) != 1 {
+		t.Fatalf("changed = %v, pending = %v, want no change and one pending member", changed, pending)
+	}
+
+	assignAddresses(pool, "sample", nil)
+	if len(pool.Status.Allocations) != 1 || pool.Status.Allocations[0].Owner != "other" {
+		t.Fatalf("allocations = %v, want only other's", pool.Status.Allocations)
+	}
+}
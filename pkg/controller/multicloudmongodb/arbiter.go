@@ -0,0 +1,99 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"sort"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+)
+
+// witness集群只部署仲裁节点，将该集群上mongo cr的members覆盖为0；没有witness集群时删除op
+func ensureWitnessOp(params *MultiCloudDBParams, cluster string) error {
+	opName := fmt.Sprintf("%s-%s", params.MultiCloudMongoDB.Name, "witness")
+	if cluster == "" {
+		return params.Fleet.RemoveOverride(opName, params.MultiCloudMongoDB.Namespace)
+	}
+
+	opLabel := k8s.GenerateInitLabel(params.MultiCloudMongoDB.Labels, params.MultiCloudMongoDB.Name)
+	op := karmada.GenerateMongoOPWithValue(opName,
+		params.MultiCloudMongoDB.Namespace,
+		cluster,
+		opLabel,
+		params.MultiCloudMongoDB,
+		"/spec/members",
+		karmadaPolicyv1alpha1.OverriderOpReplace,
+		"0")
+	return params.Fleet.Override(params.MultiCloudMongoDB, op)
+}
+
+// 按spec.config.arbiterPlacement确定仲裁节点所在集群，返回选择的集群
+func placeArbiter(params *MultiCloudDBParams) (string, error) {
+	cr := params.MultiCloudMongoDB
+	placement := cr.Spec.Config.ArbiterPlacement
+	if !cr.Spec.Config.Arbiter || placement == "" || params.SchedulerResult == nil {
+		return "", nil
+	}
+
+	clusters, err := params.Fleet.ListClusters()
+	if err != nil {
+		return "", err
+	}
+	if !cr.Spec.Config.IsAutoArbiterPlacement() {
+		// 指定的集群不存在时返回错误，记录到condition中
+		for i := range clusters {
+			if clusters[i].Name == placement {
+				params.SchedulerResult.SetArbiter(placement)
+				return placement, nil
+			}
+		}
+		return "", fmt.Errorf("arbiter cluster %s not found", placement)
+	}
+	// 仲裁节点当前下发的集群，仍然满足条件时不迁移
+	current := ""
+	pp, err := params.Fleet.GetPlacement(fmt.Sprintf("%s-mongodb-arbiter-pp", cr.Name), cr.Namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+	if err == nil && pp.Spec.Placement.ClusterAffinity != nil && len(pp.Spec.Placement.ClusterAffinity.ClusterNames) > 0 {
+		current = pp.Spec.Placement.ClusterAffinity.ClusterNames[0]
+	}
+	cluster := autoArbiterCluster(clusters, params.SchedulerResult.Votes(false), params.UnavailableClusters(), current)
+	if cluster == "" {
+		return "", fmt.Errorf("no cluster available for arbiter")
+	}
+	params.SchedulerResult.SetArbiter(cluster)
+	return cluster, nil
+}
+
+// 优先选择没有数据成员的集群，没有时选择数据成员最少的集群；数据成员数相同时当前集群优先，其次按名称排序
+func autoArbiterCluster(clusters []karmadaClusterv1alpha1.Cluster, members map[string]int, unavailableClusters []string, current string) string {
+	unavailable := make(map[string]bool, len(unavailableClusters))
+	for _, c := range unavailableClusters {
+		unavailable[c] = true
+	}
+	candidates := make([]string, 0, len(clusters))
+	for i := range clusters {
+		if !unavailable[clusters[i].Name] {
+			candidates = append(candidates, clusters[i].Name)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if members[a] != members[b] {
+			return members[a] < members[b]
+		}
+		if (a == current) != (b == current) {
+			return a == current
+		}
+		return a < b
+	})
+	return candidates[0]
+}
@@ -0,0 +1,98 @@
+package multicloudmongodb
+
+import (
+	"testing"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/fleet"
+	"github.com/fedstate/fedstate/pkg/logi"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+func TestAutoArbiterCluster(t *testing.T) {
+	clusters := []karmadaClusterv1alpha1.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "c1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c3"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c4"}},
+	}
+	tests := []struct {
+		name    string
+		members map[string]int
+		failed  []string
+		current string
+		want    string
+	}{
+		{name: "cluster without members", members: map[string]int{"c1": 2, "c2": 2}, want: "c3"},
+		{name: "keep current cluster", members: map[string]int{"c1": 2, "c2": 2}, current: "c4", want: "c4"},
+		{name: "move from data cluster", members: map[string]int{"c1": 2, "c2": 2}, current: "c1", want: "c3"},
+		{name: "skip failed cluster", members: map[string]int{"c1": 2, "c2": 2}, failed: []string{"c3"}, want: "c4"},
+		{name: "smallest cluster", members: map[string]int{"c1": 2, "c2": 1, "c3": 2, "c4": 1}, want: "c2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoArbiterCluster(clusters, tt.members, tt.failed, tt.current); got != tt.want {
+				t.Errorf("autoArbiterCluster() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceArbiter(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(middlewarev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(karmadaClusterv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(karmadaPolicyv1alpha1.AddToScheme(scheme))
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&karmadaClusterv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Labels: map[string]string{"vip": "10.0.1.1"}}},
+		&karmadaClusterv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c2", Labels: map[string]string{"vip": "10.0.2.1"}}},
+		&karmadaClusterv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c3", Labels: map[string]string{"vip": "10.0.3.1"}}},
+	).Build()
+	tests := []struct {
+		name      string
+		placement string
+		want      string
+		wantErr   bool
+	}{
+		{name: "auto", placement: "auto", want: "c3"},
+		{name: "named cluster", placement: "c2", want: "c2"},
+		{name: "unknown cluster", placement: "c4", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.SchedulerResult{}
+			result.Append("c1", 2)
+			result.Append("c2", 1)
+			params := &MultiCloudDBParams{
+				Cli:    cli,
+				Schema: scheme,
+				Fleet:  fleet.NewKarmada(cli, scheme, nil),
+				MultiCloudMongoDB: &middlewarev1alpha1.MultiCloudMongoDB{
+					ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+					Spec: middlewarev1alpha1.MultiCloudMongoDBSpec{
+						Config: middlewarev1alpha1.ConfigSetting{Arbiter: true, ArbiterPlacement: tt.placement},
+					},
+				},
+				SchedulerResult: result,
+				Log:             logi.Log.Sugar(),
+			}
+			got, err := placeArbiter(params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("placeArbiter() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("placeArbiter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,9 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net"
 	"sort"
-	"strconv"
 	"strings"
 
 	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
@@ -19,6 +17,8 @@ import (
 	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
 	"github.com/fedstate/fedstate/pkg/driver/k8s"
 	"github.com/fedstate/fedstate/pkg/driver/karmada"
+	"github.com/fedstate/fedstate/pkg/event"
+	"github.com/fedstate/fedstate/pkg/fleet"
 	"github.com/fedstate/fedstate/pkg/model"
 )
 
@@ -30,7 +30,9 @@ type MultiCloudDBHandler interface {
 }
 
 type MultiCloudDBParams struct {
-	Cli                    client.Client
+	Cli client.Client
+	// 将资源下发到成员集群的多集群后端
+	Fleet                  fleet.Backend
 	MultiCloudMongoDB      *middlewarev1alpha1.MultiCloudMongoDB
 	ClusterToVIPMap        map[string]string
 	SchedulerResult        *model.SchedulerResult
@@ -39,6 +41,43 @@ type MultiCloudDBParams struct {
 	Log                    *zap.SugaredLogger
 	ServiceNameWithCluster map[string][]string
 	ActiveCluster          []string
+	// 成员集群上报的成员地址，cluster -> service -> host
+	MemberAddrs map[string]map[string]string
+	// 地址池分配的成员地址，cluster -> service -> address
+	PoolAddrs map[string]map[string]string
+	// 隐藏成员和延迟成员的地址，host -> kind
+	HiddenHosts map[string]string
+	// NotReady超过宽限期的集群
+	FailedClusters []string
+	Event          event.IEvent
+	// 多数派保护自动部署的仲裁节点
+	MajorityArbiter bool
+	// 排空集群上保留的成员数，替换成员完成同步前不缩容
+	DrainingMembers int
+}
+
+// 是否部署仲裁节点
+func (p *MultiCloudDBParams) ArbiterEnabled() bool {
+	return p.MultiCloudMongoDB.Spec.Config.Arbiter || p.MajorityArbiter
+}
+
+// 不能部署成员的集群：故障集群和排空的集群
+func (p *MultiCloudDBParams) UnavailableClusters() []string {
+	return append(append([]string{}, p.FailedClusters...), p.MultiCloudMongoDB.Spec.Scheduler.ExcludedClusters...)
+}
+
+func vipOpName(name, cluster string) string {
+	return fmt.Sprintf("%s-%s-vip", name, cluster)
+}
+
+// 下发到成员集群的mongo cr
+func mongoResource(cr *middlewarev1alpha1.MultiCloudMongoDB) karmadaPolicyv1alpha1.ResourceSelector {
+	return karmadaPolicyv1alpha1.ResourceSelector{
+		APIVersion: middlewarev1alpha1.GroupVersion.String(),
+		Kind:       "MongoDB",
+		Name:       cr.Name,
+		Namespace:  cr.Namespace,
+	}
 }
 
 type GetScheduleStatusHandler struct {
@@ -55,9 +94,28 @@ func (h *GetScheduleStatusHandler) Handle(params *MultiCloudDBParams) error {
 	params.Log.Debugf("start resourceversion: %s", params.MultiCloudMongoDB.ResourceVersion)
 	MultiCloudMongoDB := params.MultiCloudMongoDB
 	params.Log.Infof("start process scheduler result")
-	annotationSchedulerResult := MultiCloudMongoDB.GetAnnotations()["schedulerResult"]
+	// 外部调度结果存在时覆盖内置调度器
+	source := "Annotations"
+	annotationSchedulerResult, ok := MultiCloudMongoDB.GetAnnotations()[middlewarev1alpha1.AnnotationKeySchedulerResult]
+	if !ok {
+		source = "Builtin Scheduler"
+		result, reasons, err := ensureBuiltinSchedulerResult(params)
+		if len(reasons) > 0 {
+			source = fmt.Sprintf("%s (%s)", source, strings.Join(reasons, "; "))
+		}
+		if err != nil {
+			params.Log.Errorf("Builtin Scheduler Failed, err: %v", err)
+			params.MultiCloudMongoDB.Status.SetTypeCondition(middlewarev1alpha1.ServerScheduledResult, middlewarev1alpha1.False, "ScheduleFailed",
+				fmt.Sprintf("Get Scheduler Result From %s Failed (%s/%s): %s", source, params.MultiCloudMongoDB.Namespace, params.MultiCloudMongoDB.Name, err.Error()))
+			if err := k8s.UpdateObjectStatus(params.Cli, params.MultiCloudMongoDB); err != nil {
+				params.Log.Errorf("Update MultiCloudMongoDB Status Failed, Err: %v", err)
+			}
+			return err
+		}
+		annotationSchedulerResult = result
+	}
 	params.Log.Debugf("annotationResult: %v", annotationSchedulerResult)
-	processMessage := fmt.Sprintf("Get Scheduler Result From MultiCloudMongoDB Annotations Success (%s/%s): %v", params.MultiCloudMongoDB.Namespace, params.MultiCloudMongoDB.Name, annotationSchedulerResult)
+	processMessage := fmt.Sprintf("Get Scheduler Result From %s Success (%s/%s): %v", source, params.MultiCloudMongoDB.Namespace, params.MultiCloudMongoDB.Name, annotationSchedulerResult)
 	processReason := "GetSchedulerSuccess"
 	processStatus := middlewarev1alpha1.True
 	defer func() {
@@ -69,15 +127,34 @@ func (h *GetScheduleStatusHandler) Handle(params *MultiCloudDBParams) error {
 	err := json.Unmarshal([]byte(annotationSchedulerResult), &params.SchedulerResult)
 	if err != nil {
 		processStatus = middlewarev1alpha1.False
-		processMessage = fmt.Sprintf("Get Scheduler Result From MultiCloudMongoDB Annotations Failed (%s/%s): %s", params.MultiCloudMongoDB.Namespace, params.MultiCloudMongoDB.Name, err.Error())
+		processMessage = fmt.Sprintf("Get Scheduler Result From %s Failed (%s/%s): %s", source, params.MultiCloudMongoDB.Namespace, params.MultiCloudMongoDB.Name, err.Error())
 		processReason = "GetSchedulerFailed"
 		params.Log.Errorf("Unmarshal MultiCloudMongoDB Annotation Failed, err: %v", err)
 		return err
 	}
-	schedulerReplicaset := 0
-	for i := range params.SchedulerResult.ClusterWithReplicaset {
-		schedulerReplicaset += params.SchedulerResult.ClusterWithReplicaset[i].Replicaset
+	arbiter, err := placeArbiter(params)
+	if err != nil {
+		processStatus = middlewarev1alpha1.False
+		processMessage = fmt.Sprintf("Place Arbiter Failed (%s/%s): %s", params.MultiCloudMongoDB.Namespace, params.MultiCloudMongoDB.Name, err.Error())
+		processReason = "PlaceArbiterFailed"
+		params.Log.Errorf("Place Arbiter Failed, err: %v", err)
+		return err
+	}
+	if arbiter != "" {
+		processMessage = fmt.Sprintf("%s, arbiter in cluster %s", processMessage, arbiter)
 	}
+	note, err := ensureMajoritySafe(params)
+	if err != nil {
+		processStatus = middlewarev1alpha1.False
+		processMessage = fmt.Sprintf("Scheduler Result From %s Is Not Majority Safe (%s/%s): %s", source, params.MultiCloudMongoDB.Namespace, params.MultiCloudMongoDB.Name, err.Error())
+		processReason = "MajorityUnsafe"
+		params.Log.Errorf("Ensure Majority Safe Failed, err: %v", err)
+		return err
+	}
+	if note != "" {
+		processMessage = fmt.Sprintf("%s, %s", processMessage, note)
+	}
+	schedulerReplicaset := params.SchedulerResult.Replicaset()
 	if schedulerReplicaset == 0 {
 		params.Log.Infof("no need scheduler replicaset: %d, annotation: %v", schedulerReplicaset, annotationSchedulerResult)
 		return nil
@@ -103,13 +180,13 @@ func (h *VIPAllocatorHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDBH
 func (h *VIPAllocatorHandler) Handle(params *MultiCloudDBParams) error {
 	params.Log.Infof("get cluster vip from cluster label")
 	params.ClusterToVIPMap = make(map[string]string, len(params.SchedulerResult.ClusterWithReplicaset))
-	clusterList, err := karmada.ListClusterByLabel(params.Cli)
+	clusters, err := params.Fleet.ListClusters()
 	if err != nil {
 		params.Log.Errorf("get cluster by label failed, err: %v", err)
 		return err
 	}
-	for i := range clusterList.Items {
-		cluster := clusterList.Items[i]
+	for i := range clusters {
+		cluster := clusters[i]
 		params.ClusterToVIPMap[cluster.Name] = cluster.Labels["vip"]
 	}
 
@@ -335,7 +412,7 @@ func (h *ClusterScaleHandler) Handle(params *MultiCloudDBParams) error {
 	})
 	params.Log.Debugf("Sort Scheduler Result: %v", params.SchedulerResult.ClusterWithReplicaset)
 	for i := range params.SchedulerResult.ClusterWithReplicaset {
-		if params.SchedulerResult.ClusterWithReplicaset[i].Replicaset == 0 {
+		if params.SchedulerResult.ClusterWithReplicaset[i].Replicaset == 0 && !params.SchedulerResult.ClusterWithReplicaset[i].IsWitness() {
 			continue
 		}
 		params.ActiveCluster = append(params.ActiveCluster, params.SchedulerResult.ClusterWithReplicaset[i].Cluster)
@@ -353,20 +430,7 @@ func (h *ClusterScaleHandler) Handle(params *MultiCloudDBParams) error {
 	}
 
 	if cmFound != nil {
-		mongoNodes := cmFound.Data["datas"]
-		mongoNodesArray := strings.Split(mongoNodes, "\n")
-		hostWithSize := make(map[string]int, len(params.SchedulerResult.ClusterWithReplicaset))
-		for i := range mongoNodesArray {
-			host := strings.TrimSuffix(strings.Split(mongoNodesArray[i], "host:'")[1], "'")
-			addr := strings.Split(host, ":")[0]
-			params.Log.Debugf("host: %s", addr)
-			for cluster, _ := range params.ClusterToVIPMap {
-				params.Log.Debugf("cluster vip: %s", params.ClusterToVIPMap[cluster])
-				if addr == params.ClusterToVIPMap[cluster] {
-					hostWithSize[cluster]++
-				}
-			}
-		}
+		hostWithSize := hostConfMembers(cmFound, params.ClusterToVIPMap)
 		params.Log.Debugf("hostWithSize: %v", hostWithSize)
 		for i := range params.SchedulerResult.ClusterWithReplicaset {
 			if _, ok := hostWithSize[params.SchedulerResult.ClusterWithReplicaset[i].Cluster]; !ok {
@@ -419,7 +483,7 @@ func (h *ClusterScaleHandler) Handle(params *MultiCloudDBParams) error {
 				return err
 			}
 			servicePPLabel := k8s.GenerateServicePPLabel(params.MultiCloudMongoDB.Labels, fmt.Sprintf("%s-service-pp", params.MultiCloudMongoDB.Name))
-			svcPPList, err := karmada.ListSvcPPByLabel(params.Cli, servicePPLabel)
+			svcPPList, err := params.Fleet.ListPlacements(params.MultiCloudMongoDB.Namespace, servicePPLabel)
 			if err != nil {
 				processMessage = fmt.Sprintf("Update PP and SVC and ConfigMap failed, err: %v", err)
 				processReason = "CheckFailed"
@@ -428,7 +492,7 @@ func (h *ClusterScaleHandler) Handle(params *MultiCloudDBParams) error {
 				return err
 			}
 
-			if err := k8s.ScaleDownCleaner(params.Cli, params.Schema, serviceList, params.MultiCloudMongoDB, svcPPList, params.Log); err != nil {
+			if err := k8s.ScaleDownCleaner(params.Cli, params.Fleet, serviceList, params.MultiCloudMongoDB, svcPPList, params.Log); err != nil {
 				processMessage = fmt.Sprintf("Update PP and SVC and ConfigMap failed, err: %v", err)
 				processReason = "CheckFailed"
 				processStatus = middlewarev1alpha1.False
@@ -476,13 +540,26 @@ func (h *ClusterScaleHandler) Handle(params *MultiCloudDBParams) error {
 	}
 
 	params.Log.Debugf("Upsert SVC Slice: %v, AllCluster: %v", upsertCluster, params.ActiveCluster)
+	// 扩容只在pp中增加集群，已下发的集群由缩容和排空流程移除
+	placed := make(map[string][]string)
+	if len(upsertCluster) != 0 {
+		servicePPLabel := k8s.GenerateServicePPLabel(params.MultiCloudMongoDB.Labels, fmt.Sprintf("%s-service-pp", params.MultiCloudMongoDB.Name))
+		svcPPList, err := params.Fleet.ListPlacements(params.MultiCloudMongoDB.Namespace, servicePPLabel)
+		if err != nil {
+			params.Log.Errorf("Get SVCPPList Failed, Err: %v", err)
+			return err
+		}
+		for i := range svcPPList.Items {
+			placed[svcPPList.Items[i].Name] = svcPPList.Items[i].Spec.Placement.ClusterAffinity.ClusterNames
+		}
+	}
 	for i := 0; i < params.SchedulerResult.ClusterWithReplicaset[0].Replicaset; i++ {
 		if len(upsertCluster[i]) == 0 {
 			continue
 		}
 		serviceName := fmt.Sprintf("%s-mongodb-%d", params.MultiCloudMongoDB.Name, i)
 		label := k8s.GenerateServiceLabel(params.MultiCloudMongoDB.Labels, params.MultiCloudMongoDB.Name, serviceName)
-		svc := k8s.GenerateService(serviceName, params.MultiCloudMongoDB.Namespace, label, label, false)
+		svc := k8s.GenerateExposeService(serviceName, params.MultiCloudMongoDB.Namespace, label, label, params.MultiCloudMongoDB.Spec.Expose)
 		found := &corev1.Service{}
 		if err := k8s.Ensure(params.Cli, params.MultiCloudMongoDB, params.Schema, svc, found); err != nil {
 			params.Log.Errorf("Create SVC Failed, Err: %v", err)
@@ -490,9 +567,10 @@ func (h *ClusterScaleHandler) Handle(params *MultiCloudDBParams) error {
 		}
 
 		servicePPLabel := k8s.GenerateServicePPLabel(label, fmt.Sprintf("%s-service-pp", params.MultiCloudMongoDB.Name))
-		servicePP := karmada.GenerateServicePP(fmt.Sprintf("%s-pp", serviceName), params.MultiCloudMongoDB.Namespace, svc, servicePPLabel, upsertCluster[i]...)
-		foundPP := &karmadaPolicyv1alpha1.PropagationPolicy{}
-		if err := k8s.UpsertPPEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, servicePP, foundPP); err != nil {
+		ppName := fmt.Sprintf("%s-pp", serviceName)
+		clusters := removeDuplicates(append(append([]string{}, placed[ppName]...), upsertCluster[i]...))
+		servicePP := karmada.GenerateServicePP(ppName, params.MultiCloudMongoDB.Namespace, svc, servicePPLabel, clusters...)
+		if err := params.Fleet.Place(params.MultiCloudMongoDB, servicePP); err != nil {
 			params.Log.Errorf("Upsert SVCPP Failed, Err: %v", err)
 			return err
 		}
@@ -532,21 +610,23 @@ func (h *UpsertArbiterHandler) Handle(params *MultiCloudDBParams) error {
 	cmName := fmt.Sprintf("%s-hostconf", params.MultiCloudMongoDB.Name)
 	svcName := fmt.Sprintf("%s-mongodb-arbiter", params.MultiCloudMongoDB.Name)
 	label := k8s.GenerateArbiterLabel(params.MultiCloudMongoDB.Labels, svcName)
-	svc := k8s.GenerateService(svcName, params.MultiCloudMongoDB.Namespace, label, label, false)
+	svc := k8s.GenerateExposeService(svcName, params.MultiCloudMongoDB.Namespace, label, label, params.MultiCloudMongoDB.Spec.Expose)
 	opName := fmt.Sprintf("%s-%s", params.MultiCloudMongoDB.Name, "arbiter")
 	servicePPLabel := k8s.GenerateArbiterServicePPLabel(params.MultiCloudMongoDB.Name)
-	switch params.MultiCloudMongoDB.Spec.Config.Arbiter {
+	switch params.ArbiterEnabled() {
 	case true:
-		params.SchedulerResult.ClusterWithReplicaset[0].Arbiter = true
+		// 调度结果未指定仲裁节点所在集群时，放在成员最多的集群
+		if params.SchedulerResult.ArbiterCluster() == "" {
+			params.SchedulerResult.ClusterWithReplicaset[0].Arbiter = true
+		}
 		arbiterLabel := k8s.GenerateArbiterLabel(params.MultiCloudMongoDB.Labels, svcName)
 		mongoOp := karmada.GenerateMongoOPWithPath(opName,
 			params.MultiCloudMongoDB.Namespace,
-			params.SchedulerResult.ClusterWithReplicaset[0].Cluster,
+			params.SchedulerResult.ArbiterCluster(),
 			arbiterLabel,
 			params.MultiCloudMongoDB,
 			"/spec/arbiter")
-		found := &karmadaPolicyv1alpha1.OverridePolicy{}
-		if err := k8s.UpsertOpEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, mongoOp, found); err != nil {
+		if err := params.Fleet.Override(params.MultiCloudMongoDB, mongoOp); err != nil {
 			params.Log.Errorf("ensure op failed, err:= %v", err)
 			return err
 		}
@@ -562,14 +642,12 @@ func (h *UpsertArbiterHandler) Handle(params *MultiCloudDBParams) error {
 			}
 		}
 		servicePP := karmada.GenerateServicePP(fmt.Sprintf("%s-pp", svcName), params.MultiCloudMongoDB.Namespace, svc, servicePPLabel, cluster)
-		foundPP := &karmadaPolicyv1alpha1.PropagationPolicy{}
-		if err := k8s.UpsertPPEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, servicePP, foundPP); err != nil {
+		if err := params.Fleet.Place(params.MultiCloudMongoDB, servicePP); err != nil {
 			params.Log.Errorf("upsert svcpp failed, err: %v", err)
 			return err
 		}
 	default:
-		found := &karmadaPolicyv1alpha1.OverridePolicy{}
-		err := k8s.IsExistAndDeleted(params.Cli, opName, params.MultiCloudMongoDB.Namespace, found)
+		err := params.Fleet.RemoveOverride(opName, params.MultiCloudMongoDB.Namespace)
 		if err != nil {
 			params.Log.Errorf("is exist and deleted op failed, err: %v", err)
 			return err
@@ -580,8 +658,7 @@ func (h *UpsertArbiterHandler) Handle(params *MultiCloudDBParams) error {
 			params.Log.Errorf("is exist and deleted svc failed, err: %v", err)
 			return err
 		}
-		svcPPFound := &karmadaPolicyv1alpha1.PropagationPolicy{}
-		err = k8s.IsExistAndDeleted(params.Cli, fmt.Sprintf("%s-pp", svcName), params.MultiCloudMongoDB.Namespace, svcPPFound)
+		err = params.Fleet.RemovePlacement(fmt.Sprintf("%s-pp", svcName), params.MultiCloudMongoDB.Namespace)
 		if err != nil {
 			params.Log.Errorf("is exist and deleted svcPP failed, err: %v", err)
 			return err
@@ -613,14 +690,18 @@ func (h *HostConfigMapHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDB
 func (h *HostConfigMapHandler) Handle(params *MultiCloudDBParams) error {
 	params.Log.Infof("HostConfigMapHandler")
 	servicePPLabel := k8s.GenerateServicePPLabel(params.MultiCloudMongoDB.Labels, fmt.Sprintf("%s-service-pp", params.MultiCloudMongoDB.Name))
-	svcPPList, err := karmada.ListSvcPPByLabel(params.Cli, servicePPLabel)
+	svcPPList, err := params.Fleet.ListPlacements(params.MultiCloudMongoDB.Namespace, servicePPLabel)
 	if err != nil {
 		params.Log.Errorf("Get SVCPPList Failed, Err: %v", err)
 		return err
 	}
 
+	if err := loadMemberAddrs(params); err != nil {
+		params.Log.Errorf("Get Member Addrs Failed, Err: %v", err)
+		return err
+	}
+
 	members := &model.HostConf{}
-	var buffer bytes.Buffer
 	for i := range svcPPList.Items {
 		svcPP := svcPPList.Items[i]
 		s, err := k8s.GetSvc(params.Cli, svcPP.Spec.ResourceSelectors[0].Namespace, svcPP.Spec.ResourceSelectors[0].Name)
@@ -630,21 +711,17 @@ func (h *HostConfigMapHandler) Handle(params *MultiCloudDBParams) error {
 		}
 		for index := range svcPP.Spec.Placement.ClusterAffinity.ClusterNames {
 			cluster := svcPP.Spec.Placement.ClusterAffinity.ClusterNames[index]
-			buffer.Reset()
-			buffer.Grow(len(model.Host) + len(params.ClusterToVIPMap[cluster]) + 7)
-			buffer.WriteString(model.Host)
-			buffer.WriteString(":")
-			buffer.WriteString("'")
-			buffer.WriteString(params.ClusterToVIPMap[cluster])
-			buffer.WriteString(":")
-			buffer.WriteString(strconv.Itoa(int(s.Spec.Ports[0].NodePort)))
-			buffer.WriteString("'")
-			members.Members = append(members.Members, buffer.String())
 			params.ServiceNameWithCluster[cluster] = append(params.ServiceNameWithCluster[cluster], s.Name)
+			host := memberHost(params, s, cluster)
+			if host == "" {
+				params.Log.Infof("wait cluster %s report address of svc %s", cluster, s.Name)
+				continue
+			}
+			members.Members = append(members.Members, hostConfLine(cluster, s.Name, host))
 		}
 	}
 
-	if params.MultiCloudMongoDB.Spec.Config.Arbiter {
+	if params.ArbiterEnabled() {
 		svcName := fmt.Sprintf("%s-mongodb-arbiter", params.MultiCloudMongoDB.Name)
 		svc, err := k8s.GetSvc(params.Cli, params.MultiCloudMongoDB.Namespace, svcName)
 		if err != nil {
@@ -660,17 +737,17 @@ func (h *HostConfigMapHandler) Handle(params *MultiCloudDBParams) error {
 		}
 
 		params.ArbiterMap[cluster] = svc
-		buffer.Reset()
-		buffer.Grow(len(model.Host) + len(params.ClusterToVIPMap[cluster]) + 7)
-		buffer.WriteString(model.Host)
-		buffer.WriteString(":")
-		buffer.WriteString("'")
-		buffer.WriteString(params.ClusterToVIPMap[cluster])
-		buffer.WriteString(":")
-		buffer.WriteString(strconv.Itoa(int(svc.Spec.Ports[0].NodePort)))
-		buffer.WriteString("'")
-		members.Arbiters = append(members.Arbiters, buffer.String())
 		params.ServiceNameWithCluster[cluster] = append(params.ServiceNameWithCluster[cluster], svc.Name)
+		if host := memberHost(params, svc, cluster); host != "" {
+			members.Arbiters = append(members.Arbiters, hostConfLine(cluster, svc.Name, host))
+		} else {
+			params.Log.Infof("wait cluster %s report address of svc %s", cluster, svc.Name)
+		}
+	}
+
+	if err := appendHiddenMembers(params, members); err != nil {
+		params.Log.Errorf("Get Hidden Member Hosts Failed, Err: %v", err)
+		return err
 	}
 
 	cmName := fmt.Sprintf("%s-hostconf", params.MultiCloudMongoDB.Name)
@@ -684,8 +761,7 @@ func (h *HostConfigMapHandler) Handle(params *MultiCloudDBParams) error {
 
 	cmPPLabel := k8s.GenerateConfigMapPPLabel(cmLabel, fmt.Sprintf("%s-configmap-pp", params.MultiCloudMongoDB.Name))
 	cmPP := karmada.GenerateConfigMapPP(fmt.Sprintf("%s-pp", cm.Name), cm.Namespace, cm, cmPPLabel, params.ActiveCluster...)
-	foundPP := &karmadaPolicyv1alpha1.PropagationPolicy{}
-	if err := k8s.UpsertPPEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, cmPP, foundPP); err != nil {
+	if err := params.Fleet.Place(params.MultiCloudMongoDB, cmPP); err != nil {
 		params.Log.Errorf("Upsert CMPP Failed, Err: %v", err)
 		return err
 	}
@@ -716,8 +792,7 @@ func (h *MongoDependencyHandler) Handle(params *MultiCloudDBParams) error {
 		}
 		cmPPLabel := k8s.GenerateCustomConfigMapPPLabel(cmFound.Labels, fmt.Sprintf("%s-custom-configmap-pp", params.MultiCloudMongoDB.Name))
 		cmPP := karmada.GenerateConfigMapPP(fmt.Sprintf("%s-custom-configmap-pp", cmFound.Name), cmFound.Namespace, cmFound, cmPPLabel, params.ActiveCluster...)
-		foundPP := &karmadaPolicyv1alpha1.PropagationPolicy{}
-		if err := k8s.UpsertPPEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, cmPP, foundPP); err != nil {
+		if err := params.Fleet.Place(params.MultiCloudMongoDB, cmPP); err != nil {
 			params.Log.Errorf("Upsert CMPP Failed, Err: %v", err)
 			return err
 		}
@@ -741,12 +816,38 @@ func (h *MongoHandler) Handle(params *MultiCloudDBParams) error {
 	params.Log.Infof("MongoHandler")
 	baseLabel := k8s.BaseLabel(params.MultiCloudMongoDB.Labels, params.MultiCloudMongoDB.Name)
 	mongoCR := k8s.GenerateMongo(params.MultiCloudMongoDB.Name, params.MultiCloudMongoDB.Namespace, baseLabel, params.MultiCloudMongoDB)
+	witness := ""
+	if params.ArbiterEnabled() {
+		witness = params.SchedulerResult.WitnessCluster()
+	}
+	if witness != "" {
+		// witness集群占用的一个副本，下发后覆盖为0
+		mongoCR.Spec.Members++
+	}
+	mongoCR.Spec.Members += params.DrainingMembers
 	found := &middlewarev1alpha1.MongoDB{}
 	if err := k8s.EnsureMongoWithoutSetRef(params.Cli, mongoCR, found); err != nil {
 		params.Log.Errorf("upsert mongo failed, err: %v", err)
 		return err
 	}
 
+	if err := ensureWitnessOp(params, witness); err != nil {
+		params.Log.Errorf("Ensure Witness Op Failed, Err: %v", err)
+		return err
+	}
+	if err := ensureClusterOverrideOps(params); err != nil {
+		params.Log.Errorf("Ensure Cluster Override Op Failed, Err: %v", err)
+		return err
+	}
+	if err := ensurePrimaryPreferenceOps(params); err != nil {
+		params.Log.Errorf("Ensure Primary Preference Op Failed, Err: %v", err)
+		return err
+	}
+	if err := ensureSwitchoverOp(params); err != nil {
+		params.Log.Errorf("Ensure Switchover Op Failed, Err: %v", err)
+		return err
+	}
+
 	opName := fmt.Sprintf("%s-%s", params.MultiCloudMongoDB.Name, "init")
 	opLabel := k8s.GenerateInitLabel(params.MultiCloudMongoDB.Labels, params.MultiCloudMongoDB.Name)
 	mongoInitOp := karmada.GenerateMongoOPWithPath(opName,
@@ -756,16 +857,17 @@ func (h *MongoHandler) Handle(params *MultiCloudDBParams) error {
 		params.MultiCloudMongoDB,
 		"/spec/rsInit",
 	)
-	opFound := &karmadaPolicyv1alpha1.OverridePolicy{}
-	if err := k8s.UpsertOpEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, mongoInitOp, opFound); err != nil {
+	if err := params.Fleet.Override(params.MultiCloudMongoDB, mongoInitOp); err != nil {
 		params.Log.Errorf("Ensure MongoInitOp Failed, Err: %v", err)
 		return err
 	}
 
+	opLabelForVip := k8s.GenerateClusterVipLabel(params.MultiCloudMongoDB.Labels, params.MultiCloudMongoDB.Name)
+	vipOps := make(map[string]bool, len(params.ActiveCluster))
 	for i := range params.ActiveCluster {
 		cluster := params.ActiveCluster[i]
-		opNameForVip := fmt.Sprintf("%s-%s-vip", params.MultiCloudMongoDB.Name, cluster)
-		opLabelForVip := k8s.GenerateClusterVipLabel(params.MultiCloudMongoDB.Labels, params.MultiCloudMongoDB.Name)
+		opNameForVip := vipOpName(params.MultiCloudMongoDB.Name, cluster)
+		vipOps[opNameForVip] = true
 		mongoVipOp := karmada.GenerateMongoOPWithLabel(opNameForVip,
 			params.MultiCloudMongoDB.Namespace,
 			cluster,
@@ -773,17 +875,30 @@ func (h *MongoHandler) Handle(params *MultiCloudDBParams) error {
 			params.MultiCloudMongoDB,
 			k8s.GenerateClusterVIPLabel(params.ClusterToVIPMap[cluster]),
 		)
-
-		opFound := &karmadaPolicyv1alpha1.OverridePolicy{}
-		if err := k8s.UpsertOpEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, mongoVipOp, opFound); err != nil {
+		if err := params.Fleet.Override(params.MultiCloudMongoDB, mongoVipOp); err != nil {
 			params.Log.Errorf("Ensure MongoVipOp Failed, Err: %v", err)
 			return err
 		}
 	}
+	// 不再部署成员的集群的vip op
+	vipOpList, err := params.Fleet.ListOverrides(params.MultiCloudMongoDB.Namespace, opLabelForVip)
+	if err != nil {
+		params.Log.Errorf("List MongoVipOp Failed, Err: %v", err)
+		return err
+	}
+	for i := range vipOpList.Items {
+		if vipOps[vipOpList.Items[i].Name] {
+			continue
+		}
+		params.Log.Infof("delete vip op %s", vipOpList.Items[i].Name)
+		if err := params.Fleet.RemoveOverride(vipOpList.Items[i].Name, vipOpList.Items[i].Namespace); err != nil {
+			params.Log.Errorf("Delete MongoVipOp Failed, Err: %v", err)
+			return err
+		}
+	}
 
 	mongoPP := karmada.GenerateMongoPP(params.MultiCloudMongoDB.Name, params.MultiCloudMongoDB.Namespace, baseLabel, params.MultiCloudMongoDB, *params.SchedulerResult, params.ActiveCluster...)
-	foundPP := &karmadaPolicyv1alpha1.PropagationPolicy{}
-	if err := k8s.UpsertPPEnsure(params.Cli, params.MultiCloudMongoDB, params.Schema, mongoPP, foundPP); err != nil {
+	if err := params.Fleet.Place(params.MultiCloudMongoDB, mongoPP); err != nil {
 		params.Log.Errorf("Create MongoPP Failed, Err: %v", err)
 		return err
 	}
@@ -817,20 +932,19 @@ func (h *StatusHandler) Handle(params *MultiCloudDBParams) error {
 	}()
 
 	params.Log.Infof("StatusHandler")
-	rbName := fmt.Sprintf("%s-%s", params.MultiCloudMongoDB.Name, "mongodb")
-	rb, err := karmada.GetRBByName(params.Cli, rbName, params.MultiCloudMongoDB.Namespace)
+	aggregatedStatus, err := params.Fleet.AggregatedStatus(mongoResource(params.MultiCloudMongoDB))
 	if err != nil {
 		processMessage = fmt.Sprintf("Service Dispatch Failed And NotReady For External Service, Err: %v", err)
 		processReason = "ServerReady"
 		processStatus = middlewarev1alpha1.False
 		conditionType = middlewarev1alpha1.ServerReady
-		params.Log.Errorf("Get ResourceBinding Failed, Err: %v", err)
+		params.Log.Errorf("Get AggregatedStatus Failed, Err: %v", err)
 		return err
 	}
 	params.MultiCloudMongoDB.Status.State = middlewarev1alpha1.UnKnown
 	params.ActiveCluster = removeDuplicates(params.ActiveCluster)
-	params.Log.Debugf("AggregatedStatus len: %d, ActiveCluster len: %d,(%v)", len(rb.Status.AggregatedStatus), len(params.ActiveCluster), params.ActiveCluster)
-	if len(rb.Status.AggregatedStatus) < len(params.ActiveCluster) {
+	params.Log.Debugf("AggregatedStatus len: %d, ActiveCluster len: %d,(%v)", len(aggregatedStatus), len(params.ActiveCluster), params.ActiveCluster)
+	if len(aggregatedStatus) < len(params.ActiveCluster) {
 		zero := 0
 		params.MultiCloudMongoDB.Status.Result = make([]*middlewarev1alpha1.ServiceTopology, 0)
 		for i := range params.SchedulerResult.ClusterWithReplicaset {
@@ -855,8 +969,8 @@ func (h *StatusHandler) Handle(params *MultiCloudDBParams) error {
 	var buffer bytes.Buffer
 	var health int
 	params.MultiCloudMongoDB.Status.ExternalAddr = ""
-	for i := range rb.Status.AggregatedStatus {
-		rbStatus := rb.Status.AggregatedStatus[i]
+	for i := range aggregatedStatus {
+		rbStatus := aggregatedStatus[i]
 		if rbStatus.Status == nil {
 			continue
 		}
@@ -885,29 +999,39 @@ func (h *StatusHandler) Handle(params *MultiCloudDBParams) error {
 			CurrentRevision:     mongoStatus.CurrentRevision,
 			State:               mongoStatus.State,
 			ConnectAddrWithRole: make(map[string]string, specReplicaset),
+			MemberAddrs:         mongoStatus.MemberAddrs,
 		})
 
-		clusterVip := params.ClusterToVIPMap[rbStatus.ClusterName]
 		if svc, ok := params.ArbiterMap[rbStatus.ClusterName]; ok {
-			params.MultiCloudMongoDB.Status.Result[i].ConnectAddrWithRole[net.JoinHostPort(clusterVip, strconv.Itoa(int(svc.Spec.Ports[0].NodePort)))] = "ARBITER"
+			if host := memberHost(params, svc, rbStatus.ClusterName); host != "" {
+				params.MultiCloudMongoDB.Status.Result[i].ConnectAddrWithRole[host] = "ARBITER"
+			}
 		}
 
 		for j := range mongoStatus.ReplSet {
 			rs := mongoStatus.ReplSet[j]
-			if strings.Contains(rs.Host, clusterVip) {
+			// 隐藏成员和延迟成员不对外暴露
+			if kind, ok := params.HiddenHosts[rs.Host]; ok {
+				params.MultiCloudMongoDB.Status.Result[i].ConnectAddrWithRole[rs.Host] = strings.ToUpper(kind)
+				continue
+			}
+			if isClusterMember(params, rbStatus.ClusterName, mongoStatus, rs.Host) {
 				params.MultiCloudMongoDB.Status.Result[i].ConnectAddrWithRole[rs.Host] = rs.StateStr
-				if strings.Contains(rs.Host, clusterVip) {
-					buffer.WriteString(rs.Host)
-					buffer.WriteString(",")
-				}
+				buffer.WriteString(rs.Host)
+				buffer.WriteString(",")
 			}
 		}
 
+		if err := syncSwitchoverStatus(params, rbStatus.ClusterName, mongoStatus); err != nil {
+			params.Log.Errorf("Sync Switchover Status Failed, Err: %v", err)
+			return err
+		}
+
 		if mongoStatus.State == middlewarev1alpha1.StateRunning {
 			health++
 		}
 	}
-	if health == len(rb.Status.AggregatedStatus) {
+	if health == len(aggregatedStatus) {
 		params.MultiCloudMongoDB.Status.State = middlewarev1alpha1.Health
 	} else {
 		processMessage = fmt.Sprintf("Service Dispatch Failed And NotReady For External Service")
@@ -931,13 +1055,19 @@ func BuildMultiCloudDBHandlerChain() MultiCloudDBHandler {
 	mongoHandler := &MongoHandler{}
 	hostConfigMapHandler := &HostConfigMapHandler{}
 	upsertArbiterHandler := &UpsertArbiterHandler{}
+	upsertHiddenMemberHandler := &UpsertHiddenMemberHandler{}
+	addressPoolHandler := &AddressPoolHandler{}
+	serviceExportHandler := &ServiceExportHandler{}
 	clusterScaleHandler := &ClusterScaleHandler{}
 	vipAllocatorHandler := &VIPAllocatorHandler{}
 	getScheduleStatusHandler := &GetScheduleStatusHandler{}
 	mongoDependencyHandler := &MongoDependencyHandler{}
+	failoverHandler := &FailoverHandler{}
+	drainHandler := &DrainHandler{}
+	migrationHandler := &MigrationHandler{}
 
-	getScheduleStatusHandler.SetNext(vipAllocatorHandler).SetNext(clusterScaleHandler).
-		SetNext(upsertArbiterHandler).SetNext(hostConfigMapHandler).SetNext(mongoDependencyHandler).
+	getScheduleStatusHandler.SetNext(vipAllocatorHandler).SetNext(failoverHandler).SetNext(drainHandler).SetNext(migrationHandler).SetNext(clusterScaleHandler).
+		SetNext(upsertArbiterHandler).SetNext(upsertHiddenMemberHandler).SetNext(addressPoolHandler).SetNext(serviceExportHandler).SetNext(hostConfigMapHandler).SetNext(mongoDependencyHandler).
 		SetNext(mongoHandler).SetNext(statusHandler)
 
 	return getScheduleStatusHandler
@@ -0,0 +1,249 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+// 集群排空：调度结果已不包含排空的集群，替换成员完成初始同步、primary切换到其他集群之前，
+// 在调度结果中保留排空集群上的成员，之后再将排空集群上的成员缩容到0
+type DrainHandler struct {
+	next MultiCloudDBHandler
+}
+
+func (h *DrainHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDBHandler {
+	h.next = handler
+	return handler
+}
+
+func (h *DrainHandler) Handle(params *MultiCloudDBParams) error {
+	if len(params.MultiCloudMongoDB.Spec.Scheduler.ExcludedClusters) != 0 || params.MultiCloudMongoDB.Status.Drain != nil {
+		params.Log.Infof("DrainHandler, excluded clusters: %v", params.MultiCloudMongoDB.Spec.Scheduler.ExcludedClusters)
+		if err := drain(params); err != nil {
+			params.Log.Errorf("Drain Failed, Err: %v", err)
+			return err
+		}
+	}
+
+	if h.next != nil {
+		return h.next.Handle(params)
+	}
+	return nil
+}
+
+func drain(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	leaving, err := leavingMembers(params, cr.Spec.Scheduler.ExcludedClusters)
+	if err != nil {
+		return err
+	}
+	status := cr.Status.Drain
+	if len(leaving) == 0 {
+		if status == nil || status.Phase == middlewarev1alpha1.DrainPhaseCompleted {
+			return nil
+		}
+		now := metav1.Now()
+		status.Phase = middlewarev1alpha1.DrainPhaseCompleted
+		status.Message = fmt.Sprintf("clusters %s drained", strings.Join(status.Clusters, ","))
+		status.CompletionTime = &now
+		params.Log.Infof("drain: %s", status.Message)
+		return k8s.UpdateObjectStatus(params.Cli, cr)
+	}
+
+	clusters := make([]string, 0, len(leaving))
+	for c := range leaving {
+		clusters = append(clusters, c)
+	}
+	sort.Strings(clusters)
+	phase, message, err := evacuate(params, leaving)
+	if err != nil {
+		return err
+	}
+	if status == nil || strings.Join(status.Clusters, ",") != strings.Join(clusters, ",") || status.Phase == middlewarev1alpha1.DrainPhaseCompleted {
+		now := metav1.Now()
+		status = &middlewarev1alpha1.DrainStatus{
+			Clusters:  clusters,
+			StartTime: &now,
+		}
+	} else if status.Phase == phase && status.Message == message {
+		return nil
+	}
+	params.Log.Infof("drain %s: %s", phase, message)
+	status.Phase = phase
+	status.Message = message
+	cr.Status.Drain = status
+	return k8s.UpdateObjectStatus(params.Cli, cr)
+}
+
+// 仍有成员的集群及其成员数，调度结果中仍包含的集群和故障集群不处理
+func leavingMembers(params *MultiCloudDBParams, clusters []string) (map[string]int, error) {
+	scheduled := make(map[string]bool, len(params.SchedulerResult.ClusterWithReplicaset))
+	for _, c := range params.SchedulerResult.ClusterWithReplicaset {
+		if c.Replicaset > 0 {
+			scheduled[c.Cluster] = true
+		}
+	}
+	failed := make(map[string]bool, len(params.FailedClusters))
+	for _, c := range params.FailedClusters {
+		failed[c] = true
+	}
+	current, err := currentMembers(params)
+	if err != nil {
+		return nil, err
+	}
+
+	leaving := make(map[string]int)
+	for _, cluster := range clusters {
+		if failed[cluster] {
+			continue
+		}
+		if scheduled[cluster] {
+			params.Log.Warnf("scheduler result still places members in cluster %s", cluster)
+			continue
+		}
+		if n := current[cluster]; n > 0 {
+			leaving[cluster] = n
+		}
+	}
+	return leaving, nil
+}
+
+// 各集群当前的数据成员数，取hostconf和成员集群上报的较大值
+func currentMembers(params *MultiCloudDBParams) (map[string]int, error) {
+	cr := params.MultiCloudMongoDB
+	members := map[string]int{}
+	cm, err := k8s.GetConfigMap(params.Cli, fmt.Sprintf("%s-hostconf", cr.Name), cr.Namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		members = hostConfMembers(cm, params.ClusterToVIPMap)
+	}
+	for _, result := range cr.Status.Result {
+		if result.ReplicasetStatus != nil && *result.ReplicasetStatus > members[result.Cluster] {
+			members[result.Cluster] = *result.ReplicasetStatus
+		}
+	}
+	return members, nil
+}
+
+// 按替换成员的同步状态和primary所在集群决定离开集群上的成员是否保留，返回当前阶段
+func evacuate(params *MultiCloudDBParams, leaving map[string]int) (middlewarev1alpha1.DrainPhase, string, error) {
+	cr := params.MultiCloudMongoDB
+	clusters := make([]string, 0, len(leaving))
+	for c := range leaving {
+		clusters = append(clusters, c)
+	}
+	sort.Strings(clusters)
+
+	synced, message := replacementsSynced(cr, params.SchedulerResult)
+	if !synced {
+		keepMembers(params, clusters, leaving)
+		return middlewarev1alpha1.DrainPhaseWaitingSync, message, nil
+	}
+	if primary := primaryCluster(cr); leaving[primary] > 0 {
+		target, err := requestSwitchover(params)
+		if err != nil {
+			return "", "", err
+		}
+		keepMembers(params, clusters, leaving)
+		return middlewarev1alpha1.DrainPhaseSteppingDown, fmt.Sprintf("primary in cluster %s, switch over to cluster %s", primary, target), nil
+	}
+	// 成员数为0时由ClusterScaleHandler缩容并清理service和pp
+	for _, c := range clusters {
+		params.SchedulerResult.Append(c, 0)
+	}
+	return middlewarev1alpha1.DrainPhaseScalingDown, fmt.Sprintf("scale down members in clusters %s", strings.Join(clusters, ",")), nil
+}
+
+func keepMembers(params *MultiCloudDBParams, clusters []string, leaving map[string]int) {
+	for _, c := range clusters {
+		params.SchedulerResult.Append(c, leaving[c])
+		params.DrainingMembers += leaving[c]
+	}
+}
+
+// 调度结果中的集群上处于PRIMARY或SECONDARY状态的成员数都达到调度的副本数时，替换成员完成同步
+func replacementsSynced(cr *middlewarev1alpha1.MultiCloudMongoDB, target *model.SchedulerResult) (bool, string) {
+	ready := make(map[string]int, len(cr.Status.Result))
+	for _, result := range cr.Status.Result {
+		for _, role := range result.ConnectAddrWithRole {
+			if role == mgo.Primary || role == mgo.Secondary {
+				ready[result.Cluster]++
+			}
+		}
+	}
+	pending := make([]string, 0)
+	for cluster, n := range target.Votes(false) {
+		if ready[cluster] < n {
+			pending = append(pending, fmt.Sprintf("cluster %s %d/%d", cluster, ready[cluster], n))
+		}
+	}
+	if len(pending) == 0 {
+		return true, ""
+	}
+	sort.Strings(pending)
+	return false, fmt.Sprintf("waiting for members to sync: %s", strings.Join(pending, ", "))
+}
+
+func primaryCluster(cr *middlewarev1alpha1.MultiCloudMongoDB) string {
+	for _, result := range cr.Status.Result {
+		for _, role := range result.ConnectAddrWithRole {
+			if role == mgo.Primary {
+				return result.Cluster
+			}
+		}
+	}
+	return ""
+}
+
+// 通过switchover annotation将primary切换到保留的集群，primaryPreference中的集群优先
+// 已结束的切换请求会被替换
+func requestSwitchover(params *MultiCloudDBParams) (string, error) {
+	cr := params.MultiCloudMongoDB
+	if request := cr.Annotations[middlewarev1alpha1.AnnotationKeySwitchover]; request != "" && !switchoverFinished(cr, request) {
+		return request, nil
+	}
+	votes := params.SchedulerResult.Votes(false)
+	target := ""
+	for _, c := range cr.Spec.PrimaryPreference {
+		if votes[c] > 0 {
+			target = c
+			break
+		}
+	}
+	if target == "" {
+		for _, c := range params.SchedulerResult.ClusterWithReplicaset {
+			if c.Replicaset > 0 {
+				target = c.Cluster
+				break
+			}
+		}
+	}
+	if target == "" {
+		return "", fmt.Errorf("no cluster to switch over primary")
+	}
+
+	params.Log.Infof("request switchover to cluster %s", target)
+	// 更新对象会使用apiserver返回的status覆盖本地status
+	status := cr.Status.DeepCopy()
+	status.Switchover = nil
+	if cr.Annotations == nil {
+		cr.Annotations = make(map[string]string)
+	}
+	cr.Annotations[middlewarev1alpha1.AnnotationKeySwitchover] = target
+	if err := k8s.UpdateObject(params.Cli, cr); err != nil {
+		return "", err
+	}
+	cr.Status = *status
+	return target, nil
+}
@@ -0,0 +1,74 @@
+package multicloudmongodb
+
+import (
+	"encoding/json"
+	"testing"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/logi"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+func TestEvacuate(t *testing.T) {
+	topology := func(cluster string, roles ...string) *middlewarev1alpha1.ServiceTopology {
+		result := &middlewarev1alpha1.ServiceTopology{Cluster: cluster, ConnectAddrWithRole: map[string]string{}}
+		for i, role := range roles {
+			result.ConnectAddrWithRole[cluster+":"+string(rune('0'+i))] = role
+		}
+		return result
+	}
+	tests := []struct {
+		name     string
+		result   []*middlewarev1alpha1.ServiceTopology
+		want     middlewarev1alpha1.DrainPhase
+		wantKeep int
+	}{
+		{
+			name:     "replacement in initial sync",
+			result:   []*middlewarev1alpha1.ServiceTopology{topology("c1", "PRIMARY", "SECONDARY"), topology("c2", "SECONDARY"), topology("c3", "STARTUP2")},
+			want:     middlewarev1alpha1.DrainPhaseWaitingSync,
+			wantKeep: 2,
+		},
+		{
+			name:     "primary in drained cluster",
+			result:   []*middlewarev1alpha1.ServiceTopology{topology("c1", "PRIMARY", "SECONDARY"), topology("c2", "SECONDARY"), topology("c3", "SECONDARY")},
+			want:     middlewarev1alpha1.DrainPhaseSteppingDown,
+			wantKeep: 2,
+		},
+		{
+			name:   "scale down",
+			result: []*middlewarev1alpha1.ServiceTopology{topology("c1", "SECONDARY", "SECONDARY"), topology("c2", "PRIMARY"), topology("c3", "SECONDARY")},
+			want:   middlewarev1alpha1.DrainPhaseScalingDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.SchedulerResult{}
+			if err := json.Unmarshal([]byte(`{"ClusterWithReplicaset":[{"cluster":"c2","replicaset":1},{"cluster":"c3","replicaset":1}]}`), result); err != nil {
+				t.Fatal(err)
+			}
+			cr := &middlewarev1alpha1.MultiCloudMongoDB{}
+			// 已请求切换时不更新对象
+			cr.Annotations = map[string]string{middlewarev1alpha1.AnnotationKeySwitchover: "c2"}
+			cr.Status.Result = tt.result
+			params := &MultiCloudDBParams{
+				MultiCloudMongoDB: cr,
+				SchedulerResult:   result,
+				Log:               logi.Log.Sugar(),
+			}
+			phase, _, err := evacuate(params, map[string]int{"c1": 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if phase != tt.want {
+				t.Errorf("evacuate() phase = %s, want %s", phase, tt.want)
+			}
+			if params.DrainingMembers != tt.wantKeep {
+				t.Errorf("evacuate() kept %d members, want %d", params.DrainingMembers, tt.wantKeep)
+			}
+			if got := result.Votes(false)["c1"]; got != tt.wantKeep {
+				t.Errorf("scheduler result keeps %d members in c1, want %d", got, tt.wantKeep)
+			}
+		})
+	}
+}
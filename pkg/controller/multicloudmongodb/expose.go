@@ -0,0 +1,133 @@
+package multicloudmongodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+// 根据暴露方式获取成员service在指定集群中的地址，地址未知时返回空
+func memberHost(params *MultiCloudDBParams, svc *corev1.Service, cluster string) string {
+	switch params.MultiCloudMongoDB.Spec.Expose.GetType() {
+	case middlewarev1alpha1.ExposeTypeClusterIP, middlewarev1alpha1.ExposeTypeHeadless:
+		return k8s.ServiceDNSAddr(svc.Name, svc.Namespace)
+	case middlewarev1alpha1.ExposeTypeLoadBalancer, middlewarev1alpha1.ExposeTypeHostNetwork:
+		return params.MemberAddrs[cluster][svc.Name]
+	case middlewarev1alpha1.ExposeTypeMultiClusterService:
+		return exportServiceHost(svc.Name, svc.Namespace, cluster)
+	default:
+		addr := params.ClusterToVIPMap[cluster]
+		// 使用地址池时等待分配地址
+		if params.MultiCloudMongoDB.Spec.Expose.AddressPool != "" {
+			addr = params.PoolAddrs[cluster][svc.Name]
+			if addr == "" {
+				return ""
+			}
+		}
+		return net.JoinHostPort(addr, strconv.Itoa(int(svc.Spec.Ports[0].NodePort)))
+	}
+}
+
+// 从mongo的聚合状态中获取各成员集群上报的成员地址
+func loadMemberAddrs(params *MultiCloudDBParams) error {
+	params.MemberAddrs = make(map[string]map[string]string)
+	if !params.MultiCloudMongoDB.Spec.Expose.IsReportedByMember() {
+		return nil
+	}
+
+	aggregatedStatus, err := params.Fleet.AggregatedStatus(mongoResource(params.MultiCloudMongoDB))
+	if err != nil {
+		// mongo还未下发
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for i := range aggregatedStatus {
+		rbStatus := aggregatedStatus[i]
+		if rbStatus.Status == nil {
+			continue
+		}
+		mongoStatus := &middlewarev1alpha1.MongoDBStatus{}
+		if err := json.Unmarshal(rbStatus.Status.Raw, mongoStatus); err != nil {
+			return err
+		}
+		params.MemberAddrs[rbStatus.ClusterName] = mongoStatus.MemberAddrs
+	}
+
+	return nil
+}
+
+// 判断成员地址是否属于该集群
+func isClusterMember(params *MultiCloudDBParams, cluster string, mongoStatus *middlewarev1alpha1.MongoDBStatus, host string) bool {
+	if params.MultiCloudMongoDB.Spec.Expose.GetType() == middlewarev1alpha1.ExposeTypeNodePort {
+		if params.MultiCloudMongoDB.Spec.Expose.AddressPool == "" {
+			return strings.Contains(host, params.ClusterToVIPMap[cluster])
+		}
+		for _, addr := range params.PoolAddrs[cluster] {
+			if strings.HasPrefix(host, addr+":") {
+				return true
+			}
+		}
+		return false
+	}
+	for _, addr := range mongoStatus.MemberAddrs {
+		if addr == host {
+			return true
+		}
+	}
+	return false
+}
+
+// cluster:'member1',service:'sample-mongodb-0',host:'10.29.5.103:31029'
+func hostConfLine(cluster, service, host string) string {
+	return fmt.Sprintf("%s:'%s',%s:'%s',%s:'%s'", model.Cluster, cluster, model.Service, service, model.Host, host)
+}
+
+// hostconf中各集群的数据成员数，隐藏成员和延迟成员不计入
+func hostConfMembers(cm *corev1.ConfigMap, vipMap map[string]string) map[string]int {
+	members := make(map[string]int)
+	for _, line := range strings.Split(cm.Data["datas"], "\n") {
+		if line == "" || strings.Contains(line, model.Kind+":'") {
+			continue
+		}
+		if cluster := hostConfCluster(line, vipMap); cluster != "" {
+			members[cluster]++
+		}
+	}
+	return members
+}
+
+// 获取hostconf中成员的地址
+func hostConfHosts(lines []string) []string {
+	hosts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		parts := strings.Split(line, model.Host+":'")
+		hosts = append(hosts, strings.TrimSuffix(parts[len(parts)-1], "'"))
+	}
+	return hosts
+}
+
+// 获取hostconf中成员所在的集群，未记录集群时通过vip匹配
+func hostConfCluster(line string, clusterToVIPMap map[string]string) string {
+	if strings.HasPrefix(line, model.Cluster+":'") {
+		return strings.Split(strings.TrimPrefix(line, model.Cluster+":'"), "'")[0]
+	}
+	host := strings.TrimSuffix(strings.Split(line, model.Host+":'")[1], "'")
+	addr := strings.Split(host, ":")[0]
+	for cluster := range clusterToVIPMap {
+		if addr == clusterToVIPMap[cluster] {
+			return cluster
+		}
+	}
+	return ""
+}
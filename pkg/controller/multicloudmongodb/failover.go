@@ -0,0 +1,284 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+// 集群故障后的重新调度：内置调度器已将故障集群的成员调度到健康集群，
+// 这里将故障集群从成员service的pp中移除，使hostconf不再包含故障集群的成员；
+// 故障集群上的投票成员达到多数时，副本集可能无法选出primary。控制面无法区分集群故障和网络分区，
+// 只记录需要强制修改副本集配置，通过annotation批准后才创建Reconfigure运维操作
+type FailoverHandler struct {
+	next MultiCloudDBHandler
+}
+
+func (h *FailoverHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDBHandler {
+	h.next = handler
+	return handler
+}
+
+func (h *FailoverHandler) Handle(params *MultiCloudDBParams) error {
+	if len(params.FailedClusters) != 0 {
+		params.Log.Infof("FailoverHandler, failed clusters: %v", params.FailedClusters)
+		if err := failover(params); err != nil {
+			params.Log.Errorf("Failover Failed, Err: %v", err)
+			return err
+		}
+	}
+	if err := approveForceReconfig(params); err != nil {
+		params.Log.Errorf("Force Reconfig Failed, Err: %v", err)
+		return err
+	}
+
+	if h.next != nil {
+		return h.next.Handle(params)
+	}
+	return nil
+}
+
+func failover(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	servicePPLabel := k8s.GenerateServicePPLabel(cr.Labels, fmt.Sprintf("%s-service-pp", cr.Name))
+	svcPPList, err := params.Fleet.ListPlacements(cr.Namespace, servicePPLabel)
+	if err != nil {
+		return err
+	}
+	failed := make(map[string]bool, len(params.FailedClusters))
+	for _, c := range params.FailedClusters {
+		failed[c] = true
+	}
+	// 只处理成员service仍然下发到的故障集群
+	pending := make(map[string]bool)
+	for i := range svcPPList.Items {
+		for _, c := range svcPPList.Items[i].Spec.Placement.ClusterAffinity.ClusterNames {
+			if failed[c] {
+				pending[c] = true
+			}
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	clusters := make([]string, 0, len(pending))
+	for c := range pending {
+		clusters = append(clusters, c)
+	}
+	sort.Strings(clusters)
+
+	if !failoverRecorded(cr.Status.Failover, clusters) {
+		if err := recordFailover(params, clusters); err != nil {
+			return err
+		}
+	}
+
+	// 从成员service的pp中移除故障集群，没有集群时删除pp和service，由ClusterScaleHandler在健康集群上重建
+	for i := range svcPPList.Items {
+		pp := &svcPPList.Items[i]
+		remain := make([]string, 0, len(pp.Spec.Placement.ClusterAffinity.ClusterNames))
+		for _, c := range pp.Spec.Placement.ClusterAffinity.ClusterNames {
+			if !pending[c] {
+				remain = append(remain, c)
+			}
+		}
+		if len(remain) == len(pp.Spec.Placement.ClusterAffinity.ClusterNames) {
+			continue
+		}
+		if len(remain) == 0 {
+			params.Log.Infof("delete service and pp %s of failed clusters", pp.Name)
+			for _, rs := range pp.Spec.ResourceSelectors {
+				svc := &corev1.Service{}
+				if err := k8s.IsExistAndDeleted(params.Cli, rs.Name, cr.Namespace, svc); err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+			}
+			if err := params.Fleet.RemovePlacement(pp.Name, pp.Namespace); err != nil {
+				return err
+			}
+			continue
+		}
+		params.Log.Infof("remove failed clusters from pp %s, remain: %v", pp.Name, remain)
+		pp.Spec.Placement.ClusterAffinity.ClusterNames = remain
+		if err := params.Fleet.Place(cr, pp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func failoverRecorded(status *middlewarev1alpha1.FailoverStatus, clusters []string) bool {
+	if status == nil || len(status.FailedClusters) != len(clusters) {
+		return false
+	}
+	for i := range clusters {
+		if status.FailedClusters[i] != clusters[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 记录重新调度的决策，故障集群上的投票成员达到多数时记录强制修改副本集配置需要保留的成员，等待批准
+func recordFailover(params *MultiCloudDBParams, clusters []string) error {
+	cr := params.MultiCloudMongoDB
+	failed := make(map[string]bool, len(clusters))
+	for _, c := range clusters {
+		failed[c] = true
+	}
+
+	now := metav1.Now()
+	status := &middlewarev1alpha1.FailoverStatus{
+		FailedClusters: clusters,
+		Time:           &now,
+	}
+	survivors, votes, failedVotes, err := survivingMembers(params, failed)
+	if err != nil {
+		return err
+	}
+	status.Message = fmt.Sprintf("members of clusters %s rescheduled, %d of %d voting members lost",
+		strings.Join(clusters, ","), failedVotes, votes)
+
+	if votes > 0 && failedVotes*2 >= votes {
+		status.ForceReconfig = true
+		if len(survivors) == 0 {
+			status.Message += ", no surviving member to reconfigure"
+		} else {
+			status.Members = hostConfHosts(survivors)
+			status.Cluster = hostConfCluster(survivors[0], params.ClusterToVIPMap)
+			status.Message += fmt.Sprintf(", set annotation %s=true to force reconfig replset with members %s",
+				middlewarev1alpha1.AnnotationKeyApproveForceReconfig, strings.Join(status.Members, ","))
+		}
+	}
+
+	params.Log.Warnf("failover: %s", status.Message)
+	if params.Event != nil {
+		params.Event.CustomWarningEvent(cr, "ClusterFailover", status.Message)
+	}
+	cr.Status.Failover = status
+	return k8s.UpdateObjectStatus(params.Cli, cr)
+}
+
+// 从hostconf中统计投票成员，返回非故障集群上的成员(hostconf行)、投票成员总数和故障集群上的投票成员数
+func survivingMembers(params *MultiCloudDBParams, failed map[string]bool) ([]string, int, int, error) {
+	cmName := fmt.Sprintf("%s-hostconf", params.MultiCloudMongoDB.Name)
+	cm, err := k8s.GetConfigMap(params.Cli, cmName, params.MultiCloudMongoDB.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, 0, 0, nil
+		}
+		return nil, 0, 0, err
+	}
+
+	survivors := make([]string, 0)
+	votes, failedVotes := 0, 0
+	lines := append(strings.Split(cm.Data["datas"], "\n"), strings.Split(cm.Data["arbiters"], "\n")...)
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		cluster := hostConfCluster(line, params.ClusterToVIPMap)
+		// 隐藏成员和延迟成员不投票
+		voting := !strings.Contains(line, model.Kind+":'")
+		if voting {
+			votes++
+		}
+		if failed[cluster] {
+			if voting {
+				failedVotes++
+			}
+			continue
+		}
+		survivors = append(survivors, line)
+	}
+	return survivors, votes, failedVotes, nil
+}
+
+// 批准后创建强制修改副本集配置的运维操作并移除annotation。存活集群上报了primary时副本集仍然可用，不再强制修改
+func approveForceReconfig(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	status := cr.Status.Failover
+	if status == nil || !status.ForceReconfig || status.OpsRequest != "" || len(status.Members) == 0 ||
+		cr.Annotations[middlewarev1alpha1.AnnotationKeyApproveForceReconfig] != "true" {
+		return nil
+	}
+
+	if primary := survivingPrimary(cr, status.FailedClusters); primary != "" {
+		status.ForceReconfig = false
+		status.Message += fmt.Sprintf(", force reconfig skipped: %s is primary", primary)
+	} else {
+		ops, err := ensureFailoverOpsRequest(params, status.Cluster, status.Members, metav1.Now())
+		if err != nil {
+			return err
+		}
+		status.OpsRequest = ops
+		status.Message += fmt.Sprintf(", force reconfig approved, ops request %s", ops)
+	}
+	params.Log.Warnf("failover: %s", status.Message)
+
+	// 更新对象会使用apiserver返回的status覆盖本地status
+	saved := cr.Status.DeepCopy()
+	delete(cr.Annotations, middlewarev1alpha1.AnnotationKeyApproveForceReconfig)
+	if err := k8s.UpdateObject(params.Cli, cr); err != nil {
+		return err
+	}
+	cr.Status = *saved
+	return k8s.UpdateObjectStatus(params.Cli, cr)
+}
+
+// 非故障集群上报的primary成员
+func survivingPrimary(cr *middlewarev1alpha1.MultiCloudMongoDB, failedClusters []string) string {
+	failed := make(map[string]bool, len(failedClusters))
+	for _, c := range failedClusters {
+		failed[c] = true
+	}
+	for _, result := range cr.Status.Result {
+		if result == nil || failed[result.Cluster] {
+			continue
+		}
+		for host, role := range result.ConnectAddrWithRole {
+			if role == mgo.Primary {
+				return host
+			}
+		}
+	}
+	return ""
+}
+
+// 在存活成员所在的集群上执行Reconfigure，只保留存活的成员，新调度的成员在选出primary后加入副本集。
+// 成员集群执行前会确认副本集没有primary且移除的成员不可达
+func ensureFailoverOpsRequest(params *MultiCloudDBParams, cluster string, members []string, now metav1.Time) (string, error) {
+	cr := params.MultiCloudMongoDB
+	label := k8s.BaseLabel(cr.Labels, cr.Name)
+	ops := &middlewarev1alpha1.MongoDBOpsRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-failover-%d", cr.Name, now.Unix()),
+			Namespace: cr.Namespace,
+			Labels:    label,
+		},
+		Spec: middlewarev1alpha1.MongoDBOpsRequestSpec{
+			MongoDBRef: cr.Name,
+			Type:       middlewarev1alpha1.OpsTypeReconfigure,
+			Members:    members,
+		},
+	}
+	if err := k8s.Ensure(params.Cli, cr, params.Schema, ops, &middlewarev1alpha1.MongoDBOpsRequest{}); err != nil {
+		return "", err
+	}
+	pp := karmada.GenerateOpsRequestPP(fmt.Sprintf("%s-pp", ops.Name), cr.Namespace, ops, label, cluster)
+	if err := params.Fleet.Place(cr, pp); err != nil {
+		return "", err
+	}
+	params.Log.Infof("create ops request %s on cluster %s", ops.Name, cluster)
+	return ops.Name, nil
+}
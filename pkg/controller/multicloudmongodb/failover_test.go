@@ -0,0 +1,105 @@
+package multicloudmongodb
+
+import (
+	"context"
+	"testing"
+
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/fleet"
+	"github.com/fedstate/fedstate/pkg/logi"
+)
+
+func TestApproveForceReconfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(middlewarev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(karmadaPolicyv1alpha1.AddToScheme(scheme))
+
+	failover := func() *middlewarev1alpha1.FailoverStatus {
+		return &middlewarev1alpha1.FailoverStatus{
+			FailedClusters: []string{"c1"},
+			ForceReconfig:  true,
+			Members:        []string{"10.0.2.1:30001"},
+			Cluster:        "c2",
+		}
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		result      []*middlewarev1alpha1.ServiceTopology
+		wantOps     bool
+		wantForce   bool
+	}{
+		{
+			name:      "not approved",
+			wantForce: true,
+		},
+		{
+			name:        "approved",
+			annotations: map[string]string{middlewarev1alpha1.AnnotationKeyApproveForceReconfig: "true"},
+			result: []*middlewarev1alpha1.ServiceTopology{
+				{Cluster: "c2", ConnectAddrWithRole: map[string]string{"10.0.2.1:30001": "SECONDARY"}},
+			},
+			wantOps:   true,
+			wantForce: true,
+		},
+		{
+			name:        "surviving primary",
+			annotations: map[string]string{middlewarev1alpha1.AnnotationKeyApproveForceReconfig: "true"},
+			result: []*middlewarev1alpha1.ServiceTopology{
+				{Cluster: "c1", ConnectAddrWithRole: map[string]string{"10.0.1.1:30001": "SECONDARY"}},
+				{Cluster: "c2", ConnectAddrWithRole: map[string]string{"10.0.2.1:30001": "PRIMARY"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &middlewarev1alpha1.MultiCloudMongoDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", Annotations: tt.annotations},
+				Status: middlewarev1alpha1.MultiCloudMongoDBStatus{
+					Result:   tt.result,
+					Failover: failover(),
+				},
+			}
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr).Build()
+			params := &MultiCloudDBParams{
+				Cli:               cli,
+				Schema:            scheme,
+				Fleet:             fleet.NewKarmada(cli, scheme, nil),
+				MultiCloudMongoDB: cr,
+				Log:               logi.Log.Sugar(),
+			}
+			if err := approveForceReconfig(params); err != nil {
+				t.Fatal(err)
+			}
+
+			opsList := &middlewarev1alpha1.MongoDBOpsRequestList{}
+			if err := cli.List(context.TODO(), opsList, client.InNamespace("default")); err != nil {
+				t.Fatal(err)
+			}
+			if (len(opsList.Items) == 1) != tt.wantOps || (cr.Status.Failover.OpsRequest != "") != tt.wantOps {
+				t.Fatalf("ops requests = %d, status = %+v, want ops %v", len(opsList.Items), cr.Status.Failover, tt.wantOps)
+			}
+			if tt.wantOps && opsList.Items[0].Spec.Members[0] != "10.0.2.1:30001" {
+				t.Errorf("ops members = %v", opsList.Items[0].Spec.Members)
+			}
+			if cr.Status.Failover.ForceReconfig != tt.wantForce {
+				t.Errorf("forceReconfig = %v, want %v", cr.Status.Failover.ForceReconfig, tt.wantForce)
+			}
+			// 批准只生效一次
+			if tt.annotations != nil {
+				if _, ok := cr.Annotations[middlewarev1alpha1.AnnotationKeyApproveForceReconfig]; ok {
+					t.Errorf("annotation %s not removed", middlewarev1alpha1.AnnotationKeyApproveForceReconfig)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+type UpsertHiddenMemberHandler struct {
+	next MultiCloudDBHandler
+}
+
+func (h *UpsertHiddenMemberHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDBHandler {
+	h.next = handler
+	return handler
+}
+
+// 隐藏成员和延迟成员不参与调度，每个成员一个service，通过独立的pp下发到指定集群
+func (h *UpsertHiddenMemberHandler) Handle(params *MultiCloudDBParams) error {
+	params.Log.Infof("UpsertHiddenMemberHandler")
+	cr := params.MultiCloudMongoDB
+	cluster := hiddenMemberCluster(params)
+
+	desired := make(map[string]bool)
+	for kind, count := range map[string]int{
+		middlewarev1alpha1.MemberKindHidden:  cr.Spec.HiddenMembers.GetHidden(),
+		middlewarev1alpha1.MemberKindDelayed: cr.Spec.HiddenMembers.GetDelayed(),
+	} {
+		for i := 0; i < count; i++ {
+			svcName := hiddenMemberServiceName(cr.Name, kind, i)
+			desired[svcName] = true
+			label := k8s.MergeLabels(k8s.GenerateServiceLabel(cr.Labels, cr.Name, svcName),
+				map[string]string{middlewarev1alpha1.LabelKeyMemberKind: kind})
+			svc := k8s.GenerateExposeService(svcName, cr.Namespace, label, label, cr.Spec.Expose)
+			found := &corev1.Service{}
+			if err := k8s.Ensure(params.Cli, cr, params.Schema, svc, found); err != nil {
+				params.Log.Errorf("Create Hidden Member SVC Failed, Err: %v", err)
+				return err
+			}
+
+			ppLabel := k8s.GenerateHiddenMemberPPLabel(label, cr.Name)
+			servicePP := karmada.GenerateServicePP(fmt.Sprintf("%s-pp", svcName), cr.Namespace, svc, ppLabel, cluster)
+			if err := params.Fleet.Place(cr, servicePP); err != nil {
+				params.Log.Errorf("Upsert Hidden Member SVCPP Failed, Err: %v", err)
+				return err
+			}
+		}
+	}
+
+	// 删除缩容后多余的service和pp，成员集群中对应的成员随service一起移除
+	serviceList, err := k8s.ListService(params.Cli, cr.Namespace, k8s.BaseLabel(cr.Labels, cr.Name))
+	if err != nil {
+		params.Log.Errorf("List SVC Failed, Err: %v", err)
+		return err
+	}
+	for i := range serviceList {
+		svc := serviceList[i]
+		if svc.Labels[middlewarev1alpha1.LabelKeyMemberKind] == "" || desired[svc.Name] {
+			continue
+		}
+		params.Log.Infof("delete hidden member svc %s", svc.Name)
+		if err := params.Fleet.RemovePlacement(fmt.Sprintf("%s-pp", svc.Name), cr.Namespace); err != nil {
+			params.Log.Errorf("Delete Hidden Member SVCPP Failed, Err: %v", err)
+			return err
+		}
+		if err := k8s.DeleteObj(params.Cli, &svc); err != nil {
+			params.Log.Errorf("Delete Hidden Member SVC Failed, Err: %v", err)
+			return err
+		}
+	}
+
+	if h.next != nil {
+		return h.next.Handle(params)
+	}
+	return nil
+}
+
+// 未指定或指定的集群没有数据节点时，放在成员最多的集群
+func hiddenMemberCluster(params *MultiCloudDBParams) string {
+	cluster := params.MultiCloudMongoDB.Spec.HiddenMembers.GetCluster()
+	for _, c := range params.SchedulerResult.ClusterWithReplicaset {
+		if c.Cluster == cluster && c.Replicaset > 0 {
+			return cluster
+		}
+	}
+	if cluster != "" {
+		params.Log.Warnf("cluster %s has no data member, hidden members fall back to %s",
+			cluster, params.SchedulerResult.ClusterWithReplicaset[0].Cluster)
+	}
+	return params.SchedulerResult.ClusterWithReplicaset[0].Cluster
+}
+
+func hiddenMemberServiceName(name, kind string, index int) string {
+	return fmt.Sprintf("%s-mongodb-%s-%d", name, kind, index)
+}
+
+// 将隐藏成员和延迟成员的地址写入hostconf，记录地址用于状态展示
+func appendHiddenMembers(params *MultiCloudDBParams, members *model.HostConf) error {
+	cr := params.MultiCloudMongoDB
+	ppLabel := k8s.GenerateHiddenMemberPPLabel(cr.Labels, cr.Name)
+	ppList, err := params.Fleet.ListPlacements(cr.Namespace, ppLabel)
+	if err != nil {
+		return err
+	}
+	for i := range ppList.Items {
+		pp := ppList.Items[i]
+		svc, err := k8s.GetSvc(params.Cli, pp.Spec.ResourceSelectors[0].Namespace, pp.Spec.ResourceSelectors[0].Name)
+		if err != nil {
+			return err
+		}
+		kind := svc.Labels[middlewarev1alpha1.LabelKeyMemberKind]
+		var delay int64
+		if kind == middlewarev1alpha1.MemberKindDelayed {
+			delay = cr.Spec.HiddenMembers.GetDelaySeconds()
+		}
+		for _, cluster := range pp.Spec.Placement.ClusterAffinity.ClusterNames {
+			host := memberHost(params, svc, cluster)
+			if host == "" {
+				params.Log.Infof("wait cluster %s report address of svc %s", cluster, svc.Name)
+				continue
+			}
+			params.HiddenHosts[host] = kind
+			members.Members = append(members.Members, hiddenHostConfLine(cluster, svc.Name, kind, delay, host))
+		}
+	}
+	return nil
+}
+
+// cluster:'member1',service:'sample-mongodb-delayed-0',kind:'delayed',delay:'3600',host:'10.29.5.103:31029'
+// host需要放在最后，成员集群按host:'之后的内容解析地址
+func hiddenHostConfLine(cluster, service, kind string, delay int64, host string) string {
+	return fmt.Sprintf("%s:'%s',%s:'%s',%s:'%s',%s:'%d',%s:'%s'",
+		model.Cluster, cluster, model.Service, service, model.Kind, kind, model.Delay, delay, model.Host, host)
+}
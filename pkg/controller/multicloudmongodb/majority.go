@@ -0,0 +1,102 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"sort"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+// 多数派保护：任何一个集群(或分组)故障后，剩余的投票成员仍然是多数。
+// Arbiter策略下将仲裁节点放到其他集群，仍然不满足时返回错误。返回自动部署仲裁节点的说明
+func ensureMajoritySafe(params *MultiCloudDBParams) (string, error) {
+	guard := params.MultiCloudMongoDB.Spec.Scheduler.MajorityGuard
+	if guard == nil || params.SchedulerResult == nil {
+		return "", nil
+	}
+	clusters, err := params.Fleet.ListClusters()
+	if err != nil {
+		return "", err
+	}
+	groups := make(map[string]string, len(clusters))
+	for i := range clusters {
+		if guard.GroupByLabel != "" {
+			groups[clusters[i].Name] = clusters[i].Labels[guard.GroupByLabel]
+		}
+	}
+
+	holder, n, total := model.MajorityHolder(groupVotes(params.SchedulerResult.Votes(params.ArbiterEnabled()), groups))
+	if holder == "" {
+		return "", nil
+	}
+	if guard.Policy == middlewarev1alpha1.MajorityGuardArbiter {
+		if cluster := arbiterCluster(params, clusters, groups); cluster != "" {
+			params.Log.Infof("place arbiter in cluster %s, %s holds %d of %d votes", cluster, holder, n, total)
+			params.SchedulerResult.SetArbiter(cluster)
+			params.MajorityArbiter = true
+			return fmt.Sprintf("arbiter placed in cluster %s for majority safety", cluster), nil
+		}
+	}
+	if guard.GroupByLabel != "" {
+		return "", fmt.Errorf("clusters with label %s=%s hold %d of %d votes, the replica set cannot elect a primary if they fail",
+			guard.GroupByLabel, holder, n, total)
+	}
+	return "", fmt.Errorf("cluster %s holds %d of %d votes, the replica set cannot elect a primary if it fails", holder, n, total)
+}
+
+// 按分组汇总投票，没有分组label的集群单独作为一组
+func groupVotes(votes map[string]int, groups map[string]string) map[string]int {
+	result := make(map[string]int, len(votes))
+	for cluster, n := range votes {
+		group := groups[cluster]
+		if group == "" {
+			group = cluster
+		}
+		result[group] += n
+	}
+	return result
+}
+
+// 选择部署仲裁节点的集群，放置后需要满足多数派保护。当前仲裁节点所在集群优先，其次是没有数据成员的witness集群，
+// 再次是投票少的集群。故障集群和排空的集群不参与选择
+func arbiterCluster(params *MultiCloudDBParams, clusters []karmadaClusterv1alpha1.Cluster, groups map[string]string) string {
+	result := params.SchedulerResult
+	votes := result.Votes(false)
+	unavailable := make(map[string]bool)
+	for _, c := range params.UnavailableClusters() {
+		unavailable[c] = true
+	}
+
+	current := ""
+	if params.ArbiterEnabled() {
+		current = result.ArbiterCluster()
+	}
+	candidates := make([]string, 0, len(clusters))
+	for i := range clusters {
+		if !unavailable[clusters[i].Name] {
+			candidates = append(candidates, clusters[i].Name)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if (a == current) != (b == current) {
+			return a == current
+		}
+		if votes[a] != votes[b] {
+			return votes[a] < votes[b]
+		}
+		return a < b
+	})
+
+	for _, cluster := range candidates {
+		trial := *result
+		trial.SetArbiter(cluster)
+		if holder, _, _ := model.MajorityHolder(groupVotes(trial.Votes(true), groups)); holder == "" {
+			return cluster
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package multicloudmongodb
+
+import (
+	"encoding/json"
+	"testing"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+func TestArbiterCluster(t *testing.T) {
+	clusters := []karmadaClusterv1alpha1.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "c1", Labels: map[string]string{"region": "r1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c2", Labels: map[string]string{"region": "r2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c3", Labels: map[string]string{"region": "r2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c4", Labels: map[string]string{"region": "r3"}}},
+	}
+	regions := map[string]string{"c1": "r1", "c2": "r2", "c3": "r2", "c4": "r3"}
+
+	tests := []struct {
+		name   string
+		result string
+		groups map[string]string
+		failed []string
+		want   string
+	}{
+		{
+			name:   "witness cluster",
+			result: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":2},{"cluster":"c2","replicaset":2}]}`,
+			want:   "c3",
+		},
+		{
+			name:   "skip failed cluster",
+			result: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":2},{"cluster":"c2","replicaset":2}]}`,
+			failed: []string{"c3"},
+			want:   "c4",
+		},
+		{
+			name:   "cluster with fewer votes",
+			result: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":2},{"cluster":"c2","replicaset":1},{"cluster":"c3","replicaset":1},{"cluster":"c4","replicaset":0}]}`,
+			want:   "c4",
+		},
+		{
+			name:   "group by region",
+			result: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":2},{"cluster":"c2","replicaset":1},{"cluster":"c3","replicaset":1}]}`,
+			groups: regions,
+			want:   "c4",
+		},
+		{
+			name:   "votes capped at seven members",
+			result: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":4},{"cluster":"c2","replicaset":2},{"cluster":"c3","replicaset":3}]}`,
+			want:   "",
+		},
+		{
+			name:   "majority in one cluster",
+			result: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":3},{"cluster":"c2","replicaset":1}]}`,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.SchedulerResult{}
+			if err := json.Unmarshal([]byte(tt.result), result); err != nil {
+				t.Fatal(err)
+			}
+			params := &MultiCloudDBParams{
+				MultiCloudMongoDB: &middlewarev1alpha1.MultiCloudMongoDB{},
+				SchedulerResult:   result,
+				FailedClusters:    tt.failed,
+			}
+			if got := arbiterCluster(params, clusters, tt.groups); got != tt.want {
+				t.Errorf("arbiterCluster() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMajorityHolder(t *testing.T) {
+	tests := []struct {
+		votes map[string]int
+		want  string
+	}{
+		{votes: map[string]int{"c1": 1, "c2": 1, "c3": 1}, want: ""},
+		{votes: map[string]int{"c1": 2, "c2": 2}, want: "c2"},
+		{votes: map[string]int{"c1": 3, "c2": 1, "c3": 1}, want: "c1"},
+		{votes: map[string]int{"c1": 2, "c2": 2, "c3": 1}, want: ""},
+	}
+	for _, tt := range tests {
+		if got, _, _ := model.MajorityHolder(tt.votes); got != tt.want {
+			t.Errorf("MajorityHolder(%v) = %q, want %q", tt.votes, got, tt.want)
+		}
+	}
+}
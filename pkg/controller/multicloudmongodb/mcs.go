@@ -0,0 +1,141 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+)
+
+// 通过karmada的多集群service暴露成员。各集群上的成员service同名，
+// 因此为每个集群上的成员创建单独的service导出，再导入到所有部署成员的集群，
+// 成员使用derived service的域名加入副本集
+type ServiceExportHandler struct {
+	next MultiCloudDBHandler
+}
+
+func (h *ServiceExportHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDBHandler {
+	h.next = handler
+	return handler
+}
+
+func (h *ServiceExportHandler) Handle(params *MultiCloudDBParams) error {
+	if params.MultiCloudMongoDB.Spec.Expose.GetType() == middlewarev1alpha1.ExposeTypeMultiClusterService {
+		params.Log.Infof("ServiceExportHandler")
+		if err := ensureServiceExports(params); err != nil {
+			params.Log.Errorf("Ensure Service Exports Failed, Err: %v", err)
+			return err
+		}
+	}
+
+	if h.next != nil {
+		return h.next.Handle(params)
+	}
+	return nil
+}
+
+// 导出的service名称，karmada在导入的集群中创建derived-<name>
+func exportServiceName(service, cluster string) string {
+	return fmt.Sprintf("%s-%s", service, cluster)
+}
+
+// 成员在副本集中的地址
+func exportServiceHost(service, namespace, cluster string) string {
+	return net.JoinHostPort(k8s.DerivedServiceHost(exportServiceName(service, cluster), namespace), strconv.Itoa(k8s.DefaultPort))
+}
+
+func ensureServiceExports(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	placements, err := memberPlacements(params)
+	if err != nil {
+		return err
+	}
+
+	label := k8s.GenerateServiceExportLabel(cr.Labels, cr.Name)
+	desired := make(map[string]bool)
+	addrs := make(map[string]map[string]string)
+	for svcName, clusters := range placements {
+		svc, err := k8s.GetSvc(params.Cli, cr.Namespace, svcName)
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			name := exportServiceName(svcName, cluster)
+			desired[name] = true
+			if addrs[cluster] == nil {
+				addrs[cluster] = make(map[string]string)
+			}
+			addrs[cluster][svcName] = k8s.DerivedServiceHost(name, cr.Namespace)
+
+			// 与成员service选择相同的pod，只下发到成员所在的集群
+			exportSvc := k8s.GenerateExposeService(name, cr.Namespace, label, svc.Spec.Selector, cr.Spec.Expose)
+			found := &corev1.Service{}
+			if err := k8s.Ensure(params.Cli, cr, params.Schema, exportSvc, found); err != nil {
+				return err
+			}
+			for _, obj := range []*unstructured.Unstructured{
+				k8s.GenerateServiceExport(name, cr.Namespace, label),
+				k8s.GenerateServiceImport(name, cr.Namespace, label),
+			} {
+				if err := k8s.Ensure(params.Cli, cr, params.Schema, obj, obj.DeepCopy()); err != nil {
+					return err
+				}
+			}
+
+			exportPP := karmada.GenerateServiceExportPP(fmt.Sprintf("%s-export-pp", name), cr.Namespace, name, label, cluster)
+			if err := params.Fleet.Place(cr, exportPP); err != nil {
+				return err
+			}
+			importPP := karmada.GenerateServiceImportPP(fmt.Sprintf("%s-import-pp", name), cr.Namespace, name, label, params.ActiveCluster...)
+			if err := params.Fleet.Place(cr, importPP); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := deleteStaleServiceExports(params, label, desired); err != nil {
+		return err
+	}
+	// 成员集群通过service上的annotation获取自己的地址
+	return ensureAddressOps(params, addrs)
+}
+
+// 删除成员不再部署到的集群上的导出service及其ServiceExport、ServiceImport和pp
+func deleteStaleServiceExports(params *MultiCloudDBParams, label map[string]string, desired map[string]bool) error {
+	cr := params.MultiCloudMongoDB
+	serviceList, err := k8s.ListService(params.Cli, cr.Namespace, label)
+	if err != nil {
+		return err
+	}
+	for i := range serviceList {
+		name := serviceList[i].Name
+		if desired[name] {
+			continue
+		}
+		params.Log.Infof("delete service export %s", name)
+		for _, ppName := range []string{fmt.Sprintf("%s-export-pp", name), fmt.Sprintf("%s-import-pp", name)} {
+			if err := params.Fleet.RemovePlacement(ppName, cr.Namespace); err != nil {
+				return err
+			}
+		}
+		for _, obj := range []*unstructured.Unstructured{
+			k8s.GenerateServiceExport(name, cr.Namespace, label),
+			k8s.GenerateServiceImport(name, cr.Namespace, label),
+		} {
+			if err := k8s.DeleteObj(params.Cli, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		if err := k8s.DeleteObj(params.Cli, &serviceList[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
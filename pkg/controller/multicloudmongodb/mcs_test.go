@@ -0,0 +1,117 @@
+package multicloudmongodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/fleet"
+	"github.com/fedstate/fedstate/pkg/logi"
+)
+
+func TestExportServiceHost(t *testing.T) {
+	if got := exportServiceName("sample-mongodb-0", "c1"); got != "sample-mongodb-0-c1" {
+		t.Errorf("exportServiceName() = %q", got)
+	}
+	if got := exportServiceHost("sample-mongodb-0", "default", "c1"); got != "derived-sample-mongodb-0-c1.default.svc.cluster.local:27017" {
+		t.Errorf("exportServiceHost() = %q", got)
+	}
+}
+
+func TestEnsureServiceExports(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(middlewarev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(karmadaPolicyv1alpha1.AddToScheme(scheme))
+	scheme.AddKnownTypeWithName(k8s.ServiceExportGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(k8s.ServiceImportGVK, &unstructured.Unstructured{})
+
+	cr := &middlewarev1alpha1.MultiCloudMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", UID: "sample-uid"},
+		Spec: middlewarev1alpha1.MultiCloudMongoDBSpec{
+			Expose: middlewarev1alpha1.ExposeSetting{Type: middlewarev1alpha1.ExposeTypeMultiClusterService},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-mongodb-0", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "sample-mongodb-0"}},
+	}
+	servicePP := &karmadaPolicyv1alpha1.PropagationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-mongodb-0-pp",
+			Namespace: "default",
+			Labels:    k8s.GenerateServicePPLabel(cr.Labels, "sample-service-pp"),
+		},
+		Spec: karmadaPolicyv1alpha1.PropagationSpec{
+			ResourceSelectors: []karmadaPolicyv1alpha1.ResourceSelector{{APIVersion: "v1", Kind: "Service", Name: svc.Name}},
+			Placement: karmadaPolicyv1alpha1.Placement{
+				ClusterAffinity: &karmadaPolicyv1alpha1.ClusterAffinity{ClusterNames: []string{"c1"}},
+			},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr, svc, servicePP).Build()
+	params := &MultiCloudDBParams{
+		Cli:               cli,
+		Schema:            scheme,
+		Fleet:             fleet.NewKarmada(cli, scheme, nil),
+		MultiCloudMongoDB: cr,
+		ActiveCluster:     []string{"c1", "c2"},
+		Log:               logi.Log.Sugar(),
+	}
+	if err := ensureServiceExports(params); err != nil {
+		t.Fatal(err)
+	}
+
+	// 导出service只下发到成员所在集群，导入到所有部署成员的集群
+	if err := cli.Get(context.TODO(), client.ObjectKey{Name: "sample-mongodb-0-c1", Namespace: "default"}, &corev1.Service{}); err != nil {
+		t.Fatalf("get export service err: %v", err)
+	}
+	for name, want := range map[string][]string{
+		"sample-mongodb-0-c1-export-pp": {"c1"},
+		"sample-mongodb-0-c1-import-pp": {"c1", "c2"},
+	} {
+		pp, err := params.Fleet.GetPlacement(name, "default")
+		if err != nil {
+			t.Fatalf("get pp %s err: %v", name, err)
+		}
+		if !reflect.DeepEqual(pp.Spec.Placement.ClusterAffinity.ClusterNames, want) {
+			t.Errorf("pp %s clusters = %v, want %v", name, pp.Spec.Placement.ClusterAffinity.ClusterNames, want)
+		}
+		if pp.Spec.ResourceSelectors[0].Name != "sample-mongodb-0-c1" {
+			t.Errorf("pp %s selects %+v", name, pp.Spec.ResourceSelectors)
+		}
+	}
+
+	// 成员迁移到c2后删除c1上的导出
+	servicePP.Spec.Placement.ClusterAffinity.ClusterNames = []string{"c2"}
+	if err := cli.Update(context.TODO(), servicePP); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureServiceExports(params); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Get(context.TODO(), client.ObjectKey{Name: "sample-mongodb-0-c1", Namespace: "default"}, &corev1.Service{}); !errors.IsNotFound(err) {
+		t.Errorf("stale export service not deleted, err: %v", err)
+	}
+	for _, name := range []string{"sample-mongodb-0-c1-export-pp", "sample-mongodb-0-c1-import-pp"} {
+		if _, err := params.Fleet.GetPlacement(name, "default"); !errors.IsNotFound(err) {
+			t.Errorf("stale pp %s not removed, err: %v", name, err)
+		}
+	}
+	if _, err := params.Fleet.GetPlacement("sample-mongodb-0-c2-export-pp", "default"); err != nil {
+		t.Errorf("get pp sample-mongodb-0-c2-export-pp err: %v", err)
+	}
+}
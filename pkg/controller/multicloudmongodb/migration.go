@@ -0,0 +1,243 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+var migrationSteps = []middlewarev1alpha1.MigrationStepName{
+	middlewarev1alpha1.MigrationStepAddMembers,
+	middlewarev1alpha1.MigrationStepSyncMembers,
+	middlewarev1alpha1.MigrationStepTransferPrimary,
+	middlewarev1alpha1.MigrationStepRemoveMembers,
+	middlewarev1alpha1.MigrationStepCleanup,
+}
+
+// 迁移副本集到目标集群：内置调度器只调度到目标集群，源集群上的成员按排空的流程保留到目标集群的成员完成同步、
+// primary切换到目标集群后再缩容，最后确认源集群上的service、pp和op已清理
+type MigrationHandler struct {
+	next MultiCloudDBHandler
+}
+
+func (h *MigrationHandler) SetNext(handler MultiCloudDBHandler) MultiCloudDBHandler {
+	h.next = handler
+	return handler
+}
+
+func (h *MigrationHandler) Handle(params *MultiCloudDBParams) error {
+	if params.MultiCloudMongoDB.Spec.Migration != nil {
+		params.Log.Infof("MigrationHandler, target clusters: %v", params.MultiCloudMongoDB.Spec.Migration.TargetClusters)
+		if err := migrate(params); err != nil {
+			params.Log.Errorf("Migrate Failed, Err: %v", err)
+			return err
+		}
+	}
+
+	if h.next != nil {
+		return h.next.Handle(params)
+	}
+	return nil
+}
+
+func migrate(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	targets := append([]string{}, cr.Spec.Migration.TargetClusters...)
+	sort.Strings(targets)
+
+	status := cr.Status.Migration
+	if status == nil || strings.Join(status.TargetClusters, ",") != strings.Join(targets, ",") {
+		current, err := currentMembers(params)
+		if err != nil {
+			return err
+		}
+		status = newMigrationStatus(current, targets, cr.Spec.Scheduler.ExcludedClusters)
+		params.Log.Infof("start migration from %v to %v", status.SourceClusters, status.TargetClusters)
+	}
+	if status.Phase == middlewarev1alpha1.MigrationPhaseCompleted {
+		return nil
+	}
+
+	step, message, err := migrationStep(params, status.SourceClusters)
+	if err != nil {
+		return err
+	}
+	if !setMigrationStep(status, step, message) && cr.Status.Migration == status {
+		return nil
+	}
+	params.Log.Infof("migration step %s: %s", step, message)
+	cr.Status.Migration = status
+	return k8s.UpdateObjectStatus(params.Cli, cr)
+}
+
+func newMigrationStatus(current map[string]int, targets, excluded []string) *middlewarev1alpha1.MigrationStatus {
+	skip := make(map[string]bool, len(targets)+len(excluded))
+	for _, c := range append(append([]string{}, targets...), excluded...) {
+		skip[c] = true
+	}
+	sources := make([]string, 0, len(current))
+	for cluster, n := range current {
+		if n > 0 && !skip[cluster] {
+			sources = append(sources, cluster)
+		}
+	}
+	sort.Strings(sources)
+
+	now := metav1.Now()
+	status := &middlewarev1alpha1.MigrationStatus{
+		SourceClusters: sources,
+		TargetClusters: targets,
+		Phase:          middlewarev1alpha1.MigrationPhaseRunning,
+		StartTime:      &now,
+	}
+	for _, name := range migrationSteps {
+		status.Steps = append(status.Steps, middlewarev1alpha1.MigrationStep{
+			Name:  name,
+			State: middlewarev1alpha1.MigrationStepPending,
+		})
+	}
+	return status
+}
+
+// 根据源集群上的成员、目标集群成员的同步状态和primary所在集群确定当前步骤，步骤为空时迁移完成
+func migrationStep(params *MultiCloudDBParams, sources []string) (middlewarev1alpha1.MigrationStepName, string, error) {
+	leaving, err := leavingMembers(params, sources)
+	if err != nil {
+		return "", "", err
+	}
+	if len(leaving) != 0 {
+		added, message := membersAdded(params.MultiCloudMongoDB, params.SchedulerResult)
+		phase, evacuateMessage, err := evacuate(params, leaving)
+		if err != nil {
+			return "", "", err
+		}
+		switch phase {
+		case middlewarev1alpha1.DrainPhaseWaitingSync:
+			if !added {
+				return middlewarev1alpha1.MigrationStepAddMembers, message, nil
+			}
+			return middlewarev1alpha1.MigrationStepSyncMembers, evacuateMessage, nil
+		case middlewarev1alpha1.DrainPhaseSteppingDown:
+			return middlewarev1alpha1.MigrationStepTransferPrimary, evacuateMessage, nil
+		default:
+			return middlewarev1alpha1.MigrationStepRemoveMembers, evacuateMessage, nil
+		}
+	}
+
+	residue, err := sourceResidue(params, sources)
+	if err != nil {
+		return "", "", err
+	}
+	if len(residue) != 0 {
+		return middlewarev1alpha1.MigrationStepCleanup, fmt.Sprintf("waiting for cleanup: %s", strings.Join(residue, ", ")), nil
+	}
+	return "", fmt.Sprintf("migrated to clusters %s", strings.Join(params.MultiCloudMongoDB.Spec.Migration.TargetClusters, ",")), nil
+}
+
+// 目标集群上的成员数是否达到调度的副本数
+func membersAdded(cr *middlewarev1alpha1.MultiCloudMongoDB, target *model.SchedulerResult) (bool, string) {
+	current := make(map[string]int, len(cr.Status.Result))
+	for _, result := range cr.Status.Result {
+		if result.ReplicasetStatus != nil {
+			current[result.Cluster] = *result.ReplicasetStatus
+		}
+	}
+	pending := make([]string, 0)
+	for cluster, n := range target.Votes(false) {
+		if current[cluster] < n {
+			pending = append(pending, fmt.Sprintf("cluster %s %d/%d", cluster, current[cluster], n))
+		}
+	}
+	if len(pending) == 0 {
+		return true, ""
+	}
+	sort.Strings(pending)
+	return false, fmt.Sprintf("waiting for members to be added: %s", strings.Join(pending, ", "))
+}
+
+// 仍然下发到源集群的成员service pp和vip op
+func sourceResidue(params *MultiCloudDBParams, sources []string) ([]string, error) {
+	cr := params.MultiCloudMongoDB
+	source := make(map[string]bool, len(sources))
+	for _, c := range sources {
+		source[c] = true
+	}
+	residue := make([]string, 0)
+
+	servicePPLabel := k8s.GenerateServicePPLabel(cr.Labels, fmt.Sprintf("%s-service-pp", cr.Name))
+	svcPPList, err := params.Fleet.ListPlacements(cr.Namespace, servicePPLabel)
+	if err != nil {
+		return nil, err
+	}
+	for i := range svcPPList.Items {
+		for _, c := range svcPPList.Items[i].Spec.Placement.ClusterAffinity.ClusterNames {
+			if source[c] {
+				residue = append(residue, fmt.Sprintf("pp %s in cluster %s", svcPPList.Items[i].Name, c))
+			}
+		}
+	}
+
+	opList, err := params.Fleet.ListOverrides(cr.Namespace, k8s.GenerateClusterVipLabel(cr.Labels, cr.Name))
+	if err != nil {
+		return nil, err
+	}
+	for i := range opList.Items {
+		for _, c := range sources {
+			if opList.Items[i].Name == vipOpName(cr.Name, c) {
+				residue = append(residue, fmt.Sprintf("op %s", opList.Items[i].Name))
+			}
+		}
+	}
+	return residue, nil
+}
+
+// 更新步骤状态：之前的步骤完成，当前步骤进行中，步骤为空时迁移完成。返回状态是否变化
+func setMigrationStep(status *middlewarev1alpha1.MigrationStatus, step middlewarev1alpha1.MigrationStepName, message string) bool {
+	now := metav1.Now()
+	changed := false
+	current := len(status.Steps)
+	for i := range status.Steps {
+		if status.Steps[i].Name == step {
+			current = i
+		}
+	}
+	for i := range status.Steps {
+		s := &status.Steps[i]
+		switch {
+		case i < current && s.State != middlewarev1alpha1.MigrationStepDone:
+			if s.StartTime == nil {
+				s.StartTime = &now
+			}
+			s.State = middlewarev1alpha1.MigrationStepDone
+			s.Message = ""
+			s.CompletionTime = &now
+			changed = true
+		case i == current && (s.State != middlewarev1alpha1.MigrationStepRunning || s.Message != message):
+			if s.State != middlewarev1alpha1.MigrationStepRunning {
+				s.StartTime = &now
+			}
+			s.State = middlewarev1alpha1.MigrationStepRunning
+			s.Message = message
+			changed = true
+		case i > current && s.State != middlewarev1alpha1.MigrationStepPending:
+			// 目标集群的成员落后等情况会回到之前的步骤
+			s.State = middlewarev1alpha1.MigrationStepPending
+			s.Message = ""
+			s.StartTime = nil
+			s.CompletionTime = nil
+			changed = true
+		}
+	}
+	if step == "" {
+		status.Phase = middlewarev1alpha1.MigrationPhaseCompleted
+		status.CompletionTime = &now
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,49 @@
+package multicloudmongodb
+
+import (
+	"testing"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+)
+
+func TestSetMigrationStep(t *testing.T) {
+	status := newMigrationStatus(map[string]int{"c1": 2, "c2": 1, "c3": 0}, []string{"c3"}, []string{"c2"})
+	if len(status.SourceClusters) != 1 || status.SourceClusters[0] != "c1" {
+		t.Fatalf("source clusters = %v, want [c1]", status.SourceClusters)
+	}
+
+	states := func() []middlewarev1alpha1.MigrationStepState {
+		result := make([]middlewarev1alpha1.MigrationStepState, 0, len(status.Steps))
+		for _, s := range status.Steps {
+			result = append(result, s.State)
+		}
+		return result
+	}
+	check := func(want ...middlewarev1alpha1.MigrationStepState) {
+		t.Helper()
+		got := states()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("step states = %v, want %v", got, want)
+			}
+		}
+	}
+	pending, running, done := middlewarev1alpha1.MigrationStepPending, middlewarev1alpha1.MigrationStepRunning, middlewarev1alpha1.MigrationStepDone
+
+	if !setMigrationStep(status, middlewarev1alpha1.MigrationStepTransferPrimary, "switch over") {
+		t.Fatal("expected status change")
+	}
+	check(done, done, running, pending, pending)
+	if setMigrationStep(status, middlewarev1alpha1.MigrationStepTransferPrimary, "switch over") {
+		t.Fatal("expected no status change")
+	}
+	// 目标集群的成员重新同步
+	setMigrationStep(status, middlewarev1alpha1.MigrationStepSyncMembers, "sync")
+	check(done, running, pending, pending, pending)
+
+	setMigrationStep(status, "", "")
+	check(done, done, done, done, done)
+	if status.Phase != middlewarev1alpha1.MigrationPhaseCompleted || status.CompletionTime == nil {
+		t.Fatalf("phase = %s, want Completed", status.Phase)
+	}
+}
@@ -0,0 +1,54 @@
+package multicloudmongodb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+)
+
+// 将集群级别的覆盖配置通过op写入成员集群mongo cr的spec.memberOverrides，删除不再需要的op
+func ensureClusterOverrideOps(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	opLabel := k8s.GenerateMemberOverrideLabel(cr.Labels, cr.Name)
+
+	desired := make(map[string]bool, len(cr.Spec.ClusterOverrides))
+	for _, override := range cr.Spec.ClusterOverrides {
+		value, err := json.Marshal(override.Overrides)
+		if err != nil {
+			return err
+		}
+		opName := fmt.Sprintf("%s-%s-override", cr.Name, override.Cluster)
+		desired[opName] = true
+		op := karmada.GenerateMongoOPWithValue(opName,
+			cr.Namespace,
+			override.Cluster,
+			opLabel,
+			cr,
+			"/spec/memberOverrides",
+			karmadaPolicyv1alpha1.OverriderOpAdd,
+			string(value))
+		if err := params.Fleet.Override(cr, op); err != nil {
+			return err
+		}
+	}
+
+	opList, err := params.Fleet.ListOverrides(cr.Namespace, opLabel)
+	if err != nil {
+		return err
+	}
+	for i := range opList.Items {
+		if desired[opList.Items[i].Name] {
+			continue
+		}
+		params.Log.Infof("delete cluster override op %s", opList.Items[i].Name)
+		if err := params.Fleet.RemoveOverride(opList.Items[i].Name, opList.Items[i].Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
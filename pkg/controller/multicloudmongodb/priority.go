@@ -0,0 +1,104 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"strconv"
+
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+)
+
+// 未在primaryPreference中的集群成员priority
+const defaultMemberPriority = 1
+
+// 将primaryPreference转换为各集群成员的priority，通过op写入成员集群mongo cr的spec.memberPriority
+// 优先集群故障恢复并追上oplog后，priority更高的成员会重新发起选举成为primary
+// 手动切换primary期间暂停修改priority，避免两者同时触发选举
+func ensurePrimaryPreferenceOps(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	if switchoverActive(cr) {
+		params.Log.Infof("switchover in progress, skip primary preference")
+		return nil
+	}
+	opLabel := k8s.GenerateMemberPriorityLabel(cr.Labels, cr.Name)
+	opList, err := params.Fleet.ListOverrides(cr.Namespace, opLabel)
+	if err != nil {
+		return err
+	}
+	// 未设置过primaryPreference时不修改priority，清空后将已下发的priority恢复为默认值
+	if len(cr.Spec.PrimaryPreference) == 0 && len(opList.Items) == 0 {
+		return nil
+	}
+
+	priorities := preferredPriorities(cr)
+	desired := make(map[string]bool, len(params.ActiveCluster))
+	for _, cluster := range params.ActiveCluster {
+		priority, ok := priorities[cluster]
+		if !ok {
+			priority = defaultMemberPriority
+		}
+		opName := fmt.Sprintf("%s-%s-priority", cr.Name, cluster)
+		desired[opName] = true
+		op := karmada.GenerateMongoOPWithValue(opName,
+			cr.Namespace,
+			cluster,
+			opLabel,
+			cr,
+			"/spec/memberPriority",
+			karmadaPolicyv1alpha1.OverriderOpAdd,
+			strconv.Itoa(priority))
+		if err := params.Fleet.Override(cr, op); err != nil {
+			return err
+		}
+	}
+
+	for i := range opList.Items {
+		if desired[opList.Items[i].Name] {
+			continue
+		}
+		params.Log.Infof("delete member priority op %s", opList.Items[i].Name)
+		if err := params.Fleet.RemoveOverride(opList.Items[i].Name, opList.Items[i].Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// primaryPreference中各集群成员的priority，排除的集群和迁移中的源集群不再优先
+func preferredPriorities(cr *middlewarev1alpha1.MultiCloudMongoDB) map[string]int {
+	skip := make(map[string]bool, len(cr.Spec.Scheduler.ExcludedClusters))
+	for _, cluster := range cr.Spec.Scheduler.ExcludedClusters {
+		skip[cluster] = true
+	}
+	if migration := cr.Status.Migration; migration != nil && migration.Phase == middlewarev1alpha1.MigrationPhaseRunning {
+		for _, cluster := range migration.SourceClusters {
+			skip[cluster] = true
+		}
+	}
+
+	preference := make([]string, 0, len(cr.Spec.PrimaryPreference))
+	for _, cluster := range cr.Spec.PrimaryPreference {
+		if !skip[cluster] {
+			preference = append(preference, cluster)
+		}
+	}
+	priorities := make(map[string]int, len(preference))
+	for i, cluster := range preference {
+		if _, ok := priorities[cluster]; !ok {
+			priorities[cluster] = len(preference) - i + defaultMemberPriority
+		}
+	}
+	return priorities
+}
+
+// 设置了switchover annotation或切换还未结束
+func switchoverActive(cr *middlewarev1alpha1.MultiCloudMongoDB) bool {
+	if cr.Annotations[middlewarev1alpha1.AnnotationKeySwitchover] != "" {
+		return true
+	}
+	return cr.Status.Switchover != nil && cr.Status.Switchover.Phase == middlewarev1alpha1.SwitchoverPhaseRunning
+}
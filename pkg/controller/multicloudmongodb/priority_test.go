@@ -0,0 +1,62 @@
+package multicloudmongodb
+
+import (
+	"testing"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+)
+
+func TestPreferredPriorities(t *testing.T) {
+	tests := []struct {
+		name      string
+		excluded  []string
+		migration *middlewarev1alpha1.MigrationStatus
+		want      map[string]int
+	}{
+		{
+			name: "preference",
+			want: map[string]int{"c1": 4, "c2": 3, "c3": 2},
+		},
+		{
+			name:     "excluded cluster",
+			excluded: []string{"c1"},
+			want:     map[string]int{"c2": 3, "c3": 2},
+		},
+		{
+			name: "migration source",
+			migration: &middlewarev1alpha1.MigrationStatus{
+				SourceClusters: []string{"c2"},
+				Phase:          middlewarev1alpha1.MigrationPhaseRunning,
+			},
+			want: map[string]int{"c1": 3, "c3": 2},
+		},
+		{
+			name: "migration completed",
+			migration: &middlewarev1alpha1.MigrationStatus{
+				SourceClusters: []string{"c2"},
+				Phase:          middlewarev1alpha1.MigrationPhaseCompleted,
+			},
+			want: map[string]int{"c1": 4, "c2": 3, "c3": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &middlewarev1alpha1.MultiCloudMongoDB{
+				Spec: middlewarev1alpha1.MultiCloudMongoDBSpec{
+					PrimaryPreference: []string{"c1", "c2", "c3"},
+					Scheduler:         middlewarev1alpha1.SchedulerSetting{ExcludedClusters: tt.excluded},
+				},
+				Status: middlewarev1alpha1.MultiCloudMongoDBStatus{Migration: tt.migration},
+			}
+			got := preferredPriorities(cr)
+			if len(got) != len(tt.want) {
+				t.Fatalf("preferredPriorities() = %v, want %v", got, tt.want)
+			}
+			for cluster, priority := range tt.want {
+				if got[cluster] != priority {
+					t.Errorf("preferredPriorities() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
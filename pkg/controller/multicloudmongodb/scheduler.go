@@ -0,0 +1,488 @@
+package multicloudmongodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/fleet"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+// 内置调度器：没有外部调度结果时，根据副本数、成员集群、集群容量、传播约束和调度模式计算各集群的副本数，
+// 结果写入annotation，输入不变时结果不变。同时返回集群被跳过或限制副本数的原因
+func ensureBuiltinSchedulerResult(params *MultiCloudDBParams) (string, []string, error) {
+	cr := params.MultiCloudMongoDB
+	clusters, err := params.Fleet.ListClusters()
+	if err != nil {
+		return "", nil, err
+	}
+
+	current, ok := cr.GetAnnotations()[middlewarev1alpha1.AnnotationKeyBuiltinSchedulerResult]
+	prev := &model.SchedulerResult{}
+	if ok {
+		if err := json.Unmarshal([]byte(current), prev); err != nil {
+			params.Log.Warnf("invalid builtin scheduler result %s: %v", current, err)
+		}
+	}
+
+	capacity, reasons := clusterCapacities(params, clusters, prev)
+	result, limited, err := scheduleReplicaset(cr, clusters, prev, capacity)
+	reasons = append(reasons, limited...)
+	if err != nil {
+		return "", reasons, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", reasons, err
+	}
+	if ok && string(data) == current {
+		return current, reasons, nil
+	}
+
+	params.Log.Infof("builtin scheduler result changed, old: %s, new: %s", current, data)
+	annotations := cr.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[middlewarev1alpha1.AnnotationKeyBuiltinSchedulerResult] = string(data)
+	cr.SetAnnotations(annotations)
+	if err := k8s.UpdateObject(params.Cli, cr); err != nil {
+		return "", reasons, err
+	}
+	return string(data), reasons, nil
+}
+
+// 各集群还能部署的成员数，没有资源信息的集群不限制。已调度到集群的成员已计入集群的已分配资源，需要加回
+func clusterCapacities(params *MultiCloudDBParams, clusters []karmadaClusterv1alpha1.Cluster, prev *model.SchedulerResult) (map[string]int, []string) {
+	cr := params.MultiCloudMongoDB
+	requests := memberRequests(cr)
+	scheduled := make(map[string]int, len(prev.ClusterWithReplicaset))
+	for _, c := range prev.ClusterWithReplicaset {
+		scheduled[c.Cluster] = c.Replicaset
+	}
+
+	excluded := make(map[string]bool, len(cr.Spec.Scheduler.ExcludedClusters))
+	for _, c := range cr.Spec.Scheduler.ExcludedClusters {
+		excluded[c] = true
+	}
+	// 迁移时只调度到目标集群
+	var targets map[string]bool
+	if cr.Spec.Migration != nil {
+		targets = make(map[string]bool, len(cr.Spec.Migration.TargetClusters))
+		for _, c := range cr.Spec.Migration.TargetClusters {
+			targets[c] = true
+		}
+	}
+
+	capacity := make(map[string]int)
+	reasons := make([]string, 0)
+	for i := range clusters {
+		cluster := clusters[i]
+		if excluded[cluster.Name] {
+			capacity[cluster.Name] = 0
+			reasons = append(reasons, fmt.Sprintf("cluster %s skipped: excluded", cluster.Name))
+			continue
+		}
+		if targets != nil && !targets[cluster.Name] {
+			capacity[cluster.Name] = 0
+			reasons = append(reasons, fmt.Sprintf("cluster %s skipped: not a migration target", cluster.Name))
+			continue
+		}
+		// 开启动态调度时，NotReady超过宽限期的集群不参与调度，集群上的成员迁移到其他集群
+		if cr.Spec.SpreadConstraints.AllowDynamicScheduler {
+			if since, failed := clusterFailed(cluster, cr.Spec.SpreadConstraints.FailoverGracePeriodSeconds); failed {
+				capacity[cluster.Name] = 0
+				params.FailedClusters = append(params.FailedClusters, cluster.Name)
+				reasons = append(reasons, fmt.Sprintf("cluster %s skipped: NotReady since %s", cluster.Name, since.Format(time.RFC3339)))
+				continue
+			}
+		}
+		if sc := cr.Spec.Storage.StorageClass; sc != "" {
+			exist, err := fleet.StorageClassExists(params.Fleet, cluster.Name, sc)
+			if err != nil {
+				params.Log.Warnf("check storageClass %s in cluster %s err: %v", sc, cluster.Name, err)
+			} else if !exist {
+				capacity[cluster.Name] = 0
+				reasons = append(reasons, fmt.Sprintf("cluster %s skipped: storageClass %s not found", cluster.Name, sc))
+				continue
+			}
+		}
+		fit, reason := fitMembers(cluster, requests)
+		if fit < 0 {
+			continue
+		}
+		capacity[cluster.Name] = fit + scheduled[cluster.Name]
+		if capacity[cluster.Name] == 0 {
+			reasons = append(reasons, fmt.Sprintf("cluster %s skipped: %s", cluster.Name, reason))
+		}
+	}
+	return capacity, reasons
+}
+
+// 集群Ready condition不为True的时间超过宽限期时认为集群故障
+func clusterFailed(cluster karmadaClusterv1alpha1.Cluster, gracePeriodSeconds int32) (time.Time, bool) {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, karmadaClusterv1alpha1.ClusterConditionReady)
+	if cond == nil || cond.Status == metav1.ConditionTrue {
+		return time.Time{}, false
+	}
+	since := cond.LastTransitionTime.Time
+	return since, time.Since(since) > time.Duration(gracePeriodSeconds)*time.Second
+}
+
+// 单个数据节点请求的资源，包含exporter
+func memberRequests(cr *middlewarev1alpha1.MultiCloudMongoDB) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for name, q := range cr.Spec.Resource.Requests {
+		requests[name] = q.DeepCopy()
+	}
+	if cr.Spec.Export.Enable {
+		for name, q := range cr.Spec.Export.Resource.Requests {
+			total := requests[name]
+			total.Add(q)
+			requests[name] = total
+		}
+	}
+	requests[corev1.ResourcePods] = resource.MustParse("1")
+	return requests
+}
+
+// 集群剩余资源(allocatable - allocated)能容纳的成员数，没有资源信息时返回-1
+func fitMembers(cluster karmadaClusterv1alpha1.Cluster, requests corev1.ResourceList) (int, string) {
+	summary := cluster.Status.ResourceSummary
+	if summary == nil || len(summary.Allocatable) == 0 {
+		return -1, ""
+	}
+	fit, reason := -1, ""
+	for name, request := range requests {
+		if request.IsZero() {
+			continue
+		}
+		allocatable, ok := summary.Allocatable[name]
+		if !ok {
+			continue
+		}
+		available := allocatable.DeepCopy()
+		if allocated, ok := summary.Allocated[name]; ok {
+			available.Sub(allocated)
+		}
+		n := 0
+		if available.Sign() > 0 {
+			n = int(available.MilliValue() / request.MilliValue())
+		}
+		if fit < 0 || n < fit {
+			fit = n
+			reason = fmt.Sprintf("insufficient %s (available %s, required %s)", name, available.String(), request.String())
+		}
+	}
+	return fit, reason
+}
+
+// 计算调度结果，同时返回因容量限制副本数的集群
+func scheduleReplicaset(cr *middlewarev1alpha1.MultiCloudMongoDB, clusters []karmadaClusterv1alpha1.Cluster, prev *model.SchedulerResult, capacity map[string]int) (*model.SchedulerResult, []string, error) {
+	mode := middlewarev1alpha1.UniformScheduling
+	if cr.Spec.Scheduler.SchedulerMode != nil {
+		mode = *cr.Spec.Scheduler.SchedulerMode
+	}
+	result := &model.SchedulerResult{Mode: mode}
+	replicas := 0
+	if cr.Spec.Replicaset != nil {
+		replicas = int(*cr.Spec.Replicaset)
+	}
+	if replicas <= 0 {
+		return result, nil, nil
+	}
+
+	weights := clusterWeights(cr, mode)
+	candidates := orderClusters(cr, clusters, prev, weights, capacity)
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("no cluster available for scheduling")
+	}
+	// 成员地址只能在集群内解析时只能部署到一个集群
+	limit := replicas
+	if cr.Spec.Expose.IsClusterLocal() {
+		limit = 1
+	}
+	selected, err := spreadClusters(candidates, cr.Spec.SpreadConstraints.SpreadConstraints, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var counts []int
+	switch mode {
+	case middlewarev1alpha1.WeightScheduling:
+		w := make([]int32, len(selected))
+		for i := range selected {
+			w[i] = weights[selected[i].Name]
+		}
+		counts = divideByWeight(replicas, w)
+	case middlewarev1alpha1.PrimaryBiasedScheduling:
+		counts = dividePrimaryBiased(replicas, len(selected))
+	default:
+		counts = divideUniform(replicas, len(selected))
+	}
+	limited, err := limitByCapacity(selected, counts, capacity)
+	if err != nil {
+		return nil, limited, err
+	}
+	for i := range selected {
+		if counts[i] > 0 {
+			result.Append(selected[i].Name, counts[i])
+		}
+	}
+	return result, limited, nil
+}
+
+// 超出集群容量的成员依次分配到有剩余容量的集群
+func limitByCapacity(selected []karmadaClusterv1alpha1.Cluster, counts []int, capacity map[string]int) ([]string, error) {
+	limited := make([]string, 0)
+	overflow := 0
+	for i := range selected {
+		if c, ok := capacity[selected[i].Name]; ok && counts[i] > c {
+			limited = append(limited, fmt.Sprintf("cluster %s limited to %d members by capacity", selected[i].Name, c))
+			overflow += counts[i] - c
+			counts[i] = c
+		}
+	}
+	for overflow > 0 {
+		moved := false
+		for i := range selected {
+			if c, ok := capacity[selected[i].Name]; ok && counts[i] >= c {
+				continue
+			}
+			counts[i]++
+			overflow--
+			moved = true
+			if overflow == 0 {
+				break
+			}
+		}
+		if !moved {
+			return limited, fmt.Errorf("insufficient cluster capacity for %d members", overflow)
+		}
+	}
+	return limited, nil
+}
+
+// Weighting模式下各集群的权重，没有配置权重或其他模式时所有集群权重为1
+func clusterWeights(cr *middlewarev1alpha1.MultiCloudMongoDB, mode string) map[string]int32 {
+	if mode != middlewarev1alpha1.WeightScheduling || len(cr.Spec.Scheduler.ClusterWeights) == 0 {
+		return nil
+	}
+	weights := make(map[string]int32, len(cr.Spec.Scheduler.ClusterWeights))
+	for _, w := range cr.Spec.Scheduler.ClusterWeights {
+		weights[w.Cluster] = w.Weight
+	}
+	return weights
+}
+
+// 候选集群排序：primaryPreference中的集群按顺序在前，其次是上次调度结果中的集群，再按权重、剩余容量和名称排序
+func orderClusters(cr *middlewarev1alpha1.MultiCloudMongoDB, clusters []karmadaClusterv1alpha1.Cluster, prev *model.SchedulerResult, weights map[string]int32, capacity map[string]int) []karmadaClusterv1alpha1.Cluster {
+	preference := make(map[string]int, len(cr.Spec.PrimaryPreference))
+	for i, c := range cr.Spec.PrimaryPreference {
+		if _, ok := preference[c]; !ok {
+			preference[c] = i
+		}
+	}
+	scheduled := make(map[string]bool, len(prev.ClusterWithReplicaset))
+	for _, c := range prev.ClusterWithReplicaset {
+		if c.Replicaset > 0 {
+			scheduled[c.Cluster] = true
+		}
+	}
+
+	candidates := make([]karmadaClusterv1alpha1.Cluster, 0, len(clusters))
+	for i := range clusters {
+		if weights != nil && weights[clusters[i].Name] <= 0 {
+			continue
+		}
+		// 容量不足的集群不参与调度
+		if c, ok := capacity[clusters[i].Name]; ok && c <= 0 {
+			continue
+		}
+		candidates = append(candidates, clusters[i])
+	}
+	// 没有容量信息的集群视为容量充足
+	spare := func(name string) int {
+		if c, ok := capacity[name]; ok {
+			return c
+		}
+		return math.MaxInt32
+	}
+	rank := func(name string) int {
+		if i, ok := preference[name]; ok {
+			return i
+		}
+		if scheduled[name] {
+			return len(preference)
+		}
+		return len(preference) + 1
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].Name, candidates[j].Name
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if weights[a] != weights[b] {
+			return weights[a] > weights[b]
+		}
+		if ca, cb := spare(a), spare(b); ca != cb {
+			return ca > cb
+		}
+		return a < b
+	})
+	return candidates
+}
+
+// 按传播约束选择集群：按集群的约束限制集群数量，按region、zone、provider或label的约束在分组间轮流选择集群
+func spreadClusters(candidates []karmadaClusterv1alpha1.Cluster, constraints []karmadaPolicyv1alpha1.SpreadConstraint, limit int) ([]karmadaClusterv1alpha1.Cluster, error) {
+	minClusters := 0
+	var groupBy *karmadaPolicyv1alpha1.SpreadConstraint
+	for i := range constraints {
+		sc := constraints[i]
+		if sc.SpreadByLabel == "" && (sc.SpreadByField == "" || sc.SpreadByField == karmadaPolicyv1alpha1.SpreadByFieldCluster) {
+			if sc.MaxGroups > 0 && sc.MaxGroups < limit {
+				limit = sc.MaxGroups
+			}
+			if sc.MinGroups > minClusters {
+				minClusters = sc.MinGroups
+			}
+			continue
+		}
+		// 只使用第一个分组约束
+		if groupBy == nil {
+			groupBy = &constraints[i]
+		}
+	}
+
+	if groupBy != nil {
+		grouped, err := spreadByGroup(candidates, *groupBy)
+		if err != nil {
+			return nil, err
+		}
+		candidates = grouped
+	}
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	if len(candidates) < minClusters {
+		return nil, fmt.Errorf("%d clusters selected, spread constraint requires at least %d clusters", len(candidates), minClusters)
+	}
+	return candidates, nil
+}
+
+func spreadByGroup(candidates []karmadaClusterv1alpha1.Cluster, sc karmadaPolicyv1alpha1.SpreadConstraint) ([]karmadaClusterv1alpha1.Cluster, error) {
+	groups := make([]string, 0)
+	members := make(map[string][]karmadaClusterv1alpha1.Cluster)
+	for i := range candidates {
+		group := clusterGroup(candidates[i], sc)
+		// 没有分组信息的集群不参与调度
+		if group == "" {
+			continue
+		}
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], candidates[i])
+	}
+	if sc.MaxGroups > 0 && len(groups) > sc.MaxGroups {
+		groups = groups[:sc.MaxGroups]
+	}
+	if len(groups) < sc.MinGroups {
+		return nil, fmt.Errorf("%d groups by %s%s available, spread constraint requires at least %d groups",
+			len(groups), sc.SpreadByField, sc.SpreadByLabel, sc.MinGroups)
+	}
+
+	result := make([]karmadaClusterv1alpha1.Cluster, 0, len(candidates))
+	for i := 0; len(result) < len(candidates); i++ {
+		added := false
+		for _, group := range groups {
+			if i < len(members[group]) {
+				result = append(result, members[group][i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return result, nil
+}
+
+func clusterGroup(cluster karmadaClusterv1alpha1.Cluster, sc karmadaPolicyv1alpha1.SpreadConstraint) string {
+	if sc.SpreadByLabel != "" {
+		return cluster.Labels[sc.SpreadByLabel]
+	}
+	switch sc.SpreadByField {
+	case karmadaPolicyv1alpha1.SpreadByFieldRegion:
+		return cluster.Spec.Region
+	case karmadaPolicyv1alpha1.SpreadByFieldZone:
+		return cluster.Spec.Zone
+	case karmadaPolicyv1alpha1.SpreadByFieldProvider:
+		return cluster.Spec.Provider
+	}
+	return cluster.Name
+}
+
+// 均分副本，余数分配给排在前面的集群
+func divideUniform(replicas, clusters int) []int {
+	counts := make([]int, clusters)
+	for i := range counts {
+		counts[i] = replicas / clusters
+		if i < replicas%clusters {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// 第一个集群分配多数成员，其余成员在其他集群均分
+func dividePrimaryBiased(replicas, clusters int) []int {
+	if clusters == 1 {
+		return []int{replicas}
+	}
+	primary := replicas/2 + 1
+	return append([]int{primary}, divideUniform(replicas-primary, clusters-1)...)
+}
+
+// 按权重分配副本，余数按最大余额分配
+func divideByWeight(replicas int, weights []int32) []int {
+	counts := make([]int, len(weights))
+	var total int64
+	for _, w := range weights {
+		total += int64(w)
+	}
+	if total == 0 {
+		return divideUniform(replicas, len(weights))
+	}
+	remainders := make([]int64, len(weights))
+	assigned := 0
+	for i, w := range weights {
+		counts[i] = int(int64(replicas) * int64(w) / total)
+		remainders[i] = int64(replicas) * int64(w) % total
+		assigned += counts[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for i := 0; assigned < replicas; i++ {
+		counts[order[i%len(order)]]++
+		assigned++
+	}
+	return counts
+}
@@ -0,0 +1,187 @@
+package multicloudmongodb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/model"
+)
+
+func TestScheduleReplicaset(t *testing.T) {
+	cluster := func(name, region string) karmadaClusterv1alpha1.Cluster {
+		return karmadaClusterv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"vip": "10.0.0.1"}},
+			Spec:       karmadaClusterv1alpha1.ClusterSpec{Region: region},
+		}
+	}
+	clusters := []karmadaClusterv1alpha1.Cluster{
+		cluster("c3", "r2"), cluster("c1", "r1"), cluster("c2", "r1"),
+	}
+	spec := func(replicas int32, mode string) middlewarev1alpha1.MultiCloudMongoDBSpec {
+		return middlewarev1alpha1.MultiCloudMongoDBSpec{
+			Replicaset: &replicas,
+			Scheduler:  middlewarev1alpha1.SchedulerSetting{SchedulerMode: &mode},
+		}
+	}
+	weighted := spec(4, middlewarev1alpha1.WeightScheduling)
+	weighted.Scheduler.ClusterWeights = []middlewarev1alpha1.ClusterWeight{{Cluster: "c1", Weight: 1}, {Cluster: "c2", Weight: 3}}
+	preferred := spec(5, middlewarev1alpha1.PrimaryBiasedScheduling)
+	preferred.PrimaryPreference = []string{"c2"}
+	byRegion := spec(3, middlewarev1alpha1.UniformScheduling)
+	byRegion.SpreadConstraints.SpreadConstraints = []karmadaPolicyv1alpha1.SpreadConstraint{
+		{SpreadByField: karmadaPolicyv1alpha1.SpreadByFieldRegion, MinGroups: 2},
+		{SpreadByField: karmadaPolicyv1alpha1.SpreadByFieldCluster, MaxGroups: 2},
+	}
+	tooManyGroups := spec(3, middlewarev1alpha1.UniformScheduling)
+	tooManyGroups.SpreadConstraints.SpreadConstraints = []karmadaPolicyv1alpha1.SpreadConstraint{
+		{SpreadByField: karmadaPolicyv1alpha1.SpreadByFieldRegion, MinGroups: 3},
+	}
+
+	tests := []struct {
+		name     string
+		spec     middlewarev1alpha1.MultiCloudMongoDBSpec
+		prev     string
+		capacity map[string]int
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "uniform",
+			spec: spec(5, middlewarev1alpha1.UniformScheduling),
+			want: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":2},{"cluster":"c2","replicaset":2},{"cluster":"c3","replicaset":1}],"mode":"Uniform"}`,
+		},
+		{
+			name: "keep previous clusters",
+			spec: spec(2, middlewarev1alpha1.UniformScheduling),
+			prev: `{"ClusterWithReplicaset":[{"cluster":"c3","replicaset":1},{"cluster":"c2","replicaset":1}]}`,
+			want: `{"ClusterWithReplicaset":[{"cluster":"c2","replicaset":1},{"cluster":"c3","replicaset":1}],"mode":"Uniform"}`,
+		},
+		{
+			name: "weighting",
+			spec: weighted,
+			want: `{"ClusterWithReplicaset":[{"cluster":"c2","replicaset":3},{"cluster":"c1","replicaset":1}],"mode":"Weighting"}`,
+		},
+		{
+			name: "primary biased",
+			spec: preferred,
+			want: `{"ClusterWithReplicaset":[{"cluster":"c2","replicaset":3},{"cluster":"c1","replicaset":1},{"cluster":"c3","replicaset":1}],"mode":"PrimaryBiased"}`,
+		},
+		{
+			name: "spread by region",
+			spec: byRegion,
+			want: `{"ClusterWithReplicaset":[{"cluster":"c1","replicaset":2},{"cluster":"c3","replicaset":1}],"mode":"Uniform"}`,
+		},
+		{
+			name:    "not enough groups",
+			spec:    tooManyGroups,
+			wantErr: true,
+		},
+		{
+			name:     "skip full cluster",
+			spec:     spec(3, middlewarev1alpha1.UniformScheduling),
+			capacity: map[string]int{"c1": 0},
+			want:     `{"ClusterWithReplicaset":[{"cluster":"c2","replicaset":2},{"cluster":"c3","replicaset":1}],"mode":"Uniform"}`,
+		},
+		{
+			name:     "limit by capacity",
+			spec:     spec(5, middlewarev1alpha1.UniformScheduling),
+			capacity: map[string]int{"c1": 1, "c2": 1},
+			want:     `{"ClusterWithReplicaset":[{"cluster":"c3","replicaset":3},{"cluster":"c1","replicaset":1},{"cluster":"c2","replicaset":1}],"mode":"Uniform"}`,
+		},
+		{
+			name:     "insufficient capacity",
+			spec:     spec(3, middlewarev1alpha1.UniformScheduling),
+			capacity: map[string]int{"c1": 1, "c2": 0, "c3": 1},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := &model.SchedulerResult{}
+			if tt.prev != "" {
+				if err := json.Unmarshal([]byte(tt.prev), prev); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cr := &middlewarev1alpha1.MultiCloudMongoDB{Spec: tt.spec}
+			result, _, err := scheduleReplicaset(cr, clusters, prev, tt.capacity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scheduleReplicaset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, _ := json.Marshal(result)
+			if string(got) != tt.want {
+				t.Errorf("scheduleReplicaset() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitMembers(t *testing.T) {
+	cluster := karmadaClusterv1alpha1.Cluster{
+		Status: karmadaClusterv1alpha1.ClusterStatus{
+			ResourceSummary: &karmadaClusterv1alpha1.ResourceSummary{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+				Allocated: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2500m"),
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+			},
+		},
+	}
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	if fit, reason := fitMembers(cluster, requests); fit != 3 {
+		t.Errorf("fitMembers() = %d, %s, want 3", fit, reason)
+	}
+	if fit, _ := fitMembers(karmadaClusterv1alpha1.Cluster{}, requests); fit != -1 {
+		t.Errorf("fitMembers() without resource summary = %d, want -1", fit)
+	}
+}
+
+func TestClusterFailed(t *testing.T) {
+	cluster := func(status metav1.ConditionStatus, since time.Duration) karmadaClusterv1alpha1.Cluster {
+		return karmadaClusterv1alpha1.Cluster{
+			Status: karmadaClusterv1alpha1.ClusterStatus{
+				Conditions: []metav1.Condition{{
+					Type:               karmadaClusterv1alpha1.ClusterConditionReady,
+					Status:             status,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-since)),
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		cluster karmadaClusterv1alpha1.Cluster
+		want    bool
+	}{
+		{name: "ready", cluster: cluster(metav1.ConditionTrue, time.Hour), want: false},
+		{name: "within grace period", cluster: cluster(metav1.ConditionFalse, time.Minute), want: false},
+		{name: "not ready", cluster: cluster(metav1.ConditionFalse, time.Hour), want: true},
+		{name: "unknown", cluster: cluster(metav1.ConditionUnknown, time.Hour), want: true},
+		{name: "no condition", cluster: karmadaClusterv1alpha1.Cluster{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := clusterFailed(tt.cluster, 300); got != tt.want {
+				t.Errorf("clusterFailed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package multicloudmongodb
+
+import (
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+	"github.com/fedstate/fedstate/pkg/driver/mgo"
+)
+
+func switchoverOpName(name string) string {
+	return fmt.Sprintf("%s-switchover", name)
+}
+
+// 手动切换primary，annotation指定目标成员地址或集群，通过op将目标成员地址下发到所在集群的mongo cr
+// 由成员集群完成切换，切换结束后保留annotation和op，移除annotation后清理切换状态并恢复primaryPreference
+func ensureSwitchoverOp(params *MultiCloudDBParams) error {
+	cr := params.MultiCloudMongoDB
+	request := cr.Annotations[middlewarev1alpha1.AnnotationKeySwitchover]
+	opName := switchoverOpName(cr.Name)
+	if request == "" {
+		cr.Status.Switchover = nil
+		return params.Fleet.RemoveOverride(opName, cr.Namespace)
+	}
+
+	status := cr.Status.Switchover
+	if status == nil || (status.Target != request && status.Cluster != request) {
+		now := metav1.Now()
+		cluster, target, role := resolveSwitchoverTarget(cr, request)
+		status = &middlewarev1alpha1.SwitchoverStatus{
+			Target:    target,
+			Cluster:   cluster,
+			Phase:     middlewarev1alpha1.SwitchoverPhaseRunning,
+			StartTime: &now,
+		}
+		cr.Status.Switchover = status
+		switch {
+		case target == "":
+			// 记录请求的目标，annotation不变时不再重复切换
+			if cluster == "" {
+				status.Target = request
+			}
+			finishSwitchover(params, middlewarev1alpha1.SwitchoverPhaseFailed,
+				fmt.Sprintf("no secondary found for %s", request))
+			return nil
+		case role == mgo.Primary:
+			finishSwitchover(params, middlewarev1alpha1.SwitchoverPhaseSucceeded,
+				fmt.Sprintf("%s is already primary", target))
+		}
+		params.Log.Infof("switchover primary to %s in cluster %s", target, cluster)
+	}
+
+	opLabel := k8s.BaseLabel(cr.Labels, cr.Name)
+	op := karmada.GenerateMongoOPWithAnnotation(opName, cr.Namespace, status.Cluster, opLabel, cr,
+		map[string]string{middlewarev1alpha1.AnnotationKeySwitchover: status.Target})
+	return params.Fleet.Override(cr, op)
+}
+
+// 根据上次的状态找到目标成员所在集群，目标为集群时选择该集群中的一个secondary，该集群已有primary时直接返回primary
+func resolveSwitchoverTarget(cr *middlewarev1alpha1.MultiCloudMongoDB, request string) (string, string, string) {
+	for _, result := range cr.Status.Result {
+		if role, ok := result.ConnectAddrWithRole[request]; ok {
+			return result.Cluster, request, role
+		}
+		if result.Cluster != request {
+			continue
+		}
+		hosts := make([]string, 0, len(result.ConnectAddrWithRole))
+		for host, role := range result.ConnectAddrWithRole {
+			if role == mgo.Primary {
+				return result.Cluster, host, role
+			}
+			if role == mgo.Secondary {
+				hosts = append(hosts, host)
+			}
+		}
+		if len(hosts) == 0 {
+			return result.Cluster, "", ""
+		}
+		sort.Strings(hosts)
+		return result.Cluster, hosts[0], mgo.Secondary
+	}
+	return "", "", ""
+}
+
+// 同步成员集群上报的切换状态
+func syncSwitchoverStatus(params *MultiCloudDBParams, cluster string, mongoStatus *middlewarev1alpha1.MongoDBStatus) error {
+	status := params.MultiCloudMongoDB.Status.Switchover
+	if status == nil || status.Phase != middlewarev1alpha1.SwitchoverPhaseRunning || status.Cluster != cluster {
+		return nil
+	}
+	reported := mongoStatus.Switchover
+	if reported == nil || reported.Target != status.Target {
+		return nil
+	}
+	status.From = reported.From
+	if !reported.IsFinished() {
+		return nil
+	}
+	finishSwitchover(params, reported.Phase, reported.Message)
+	return nil
+}
+
+// 记录切换结果，annotation和op保留到用户移除annotation
+func finishSwitchover(params *MultiCloudDBParams, phase middlewarev1alpha1.SwitchoverPhase, message string) {
+	cr := params.MultiCloudMongoDB
+	now := metav1.Now()
+	cr.Status.Switchover.Phase = phase
+	cr.Status.Switchover.Message = message
+	cr.Status.Switchover.CompletionTime = &now
+	params.Log.Infof("switchover to %s %s: %s", cr.Status.Switchover.Target, phase, message)
+}
+
+// annotation请求的切换已经结束
+func switchoverFinished(cr *middlewarev1alpha1.MultiCloudMongoDB, request string) bool {
+	status := cr.Status.Switchover
+	return status != nil && status.IsFinished() && (status.Target == request || status.Cluster == request)
+}
@@ -0,0 +1,96 @@
+package multicloudmongodb
+
+import (
+	"context"
+	"testing"
+
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/fleet"
+	"github.com/fedstate/fedstate/pkg/logi"
+)
+
+func TestEnsureSwitchoverOp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(middlewarev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(karmadaPolicyv1alpha1.AddToScheme(scheme))
+
+	tests := []struct {
+		name       string
+		annotation string
+		phase      middlewarev1alpha1.SwitchoverPhase
+		wantPhase  middlewarev1alpha1.SwitchoverPhase
+		wantOp     bool
+	}{
+		{
+			name:       "succeeded switchover keeps annotation and op",
+			annotation: "c2",
+			phase:      middlewarev1alpha1.SwitchoverPhaseSucceeded,
+			wantPhase:  middlewarev1alpha1.SwitchoverPhaseSucceeded,
+			wantOp:     true,
+		},
+		{
+			name:      "annotation removed",
+			phase:     middlewarev1alpha1.SwitchoverPhaseSucceeded,
+			wantPhase: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &middlewarev1alpha1.MultiCloudMongoDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", UID: "uid"},
+				Status: middlewarev1alpha1.MultiCloudMongoDBStatus{
+					Result: []*middlewarev1alpha1.ServiceTopology{
+						{Cluster: "c1", ConnectAddrWithRole: map[string]string{"10.0.1.1:30001": "SECONDARY"}},
+						{Cluster: "c2", ConnectAddrWithRole: map[string]string{"10.0.2.1:30001": "PRIMARY"}},
+					},
+					Switchover: &middlewarev1alpha1.SwitchoverStatus{
+						Target:  "10.0.2.1:30001",
+						Cluster: "c2",
+						Phase:   tt.phase,
+					},
+				},
+			}
+			if tt.annotation != "" {
+				cr.Annotations = map[string]string{middlewarev1alpha1.AnnotationKeySwitchover: tt.annotation}
+			}
+			op := &karmadaPolicyv1alpha1.OverridePolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: switchoverOpName(cr.Name), Namespace: "default"},
+			}
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr, op).Build()
+			params := &MultiCloudDBParams{
+				Cli:               cli,
+				Schema:            scheme,
+				Fleet:             fleet.NewKarmada(cli, scheme, nil),
+				MultiCloudMongoDB: cr,
+				Log:               logi.Log.Sugar(),
+			}
+			if err := ensureSwitchoverOp(params); err != nil {
+				t.Fatal(err)
+			}
+
+			phase := middlewarev1alpha1.SwitchoverPhase("")
+			if cr.Status.Switchover != nil {
+				phase = cr.Status.Switchover.Phase
+			}
+			if phase != tt.wantPhase {
+				t.Errorf("switchover phase = %q, want %q", phase, tt.wantPhase)
+			}
+			if tt.annotation != "" && cr.Annotations[middlewarev1alpha1.AnnotationKeySwitchover] != tt.annotation {
+				t.Errorf("annotation removed after switchover finished")
+			}
+			err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: op.Name}, &karmadaPolicyv1alpha1.OverridePolicy{})
+			if (err == nil) != tt.wantOp {
+				t.Errorf("switchover op exists = %v, want %v", err == nil, tt.wantOp)
+			}
+		})
+	}
+}
@@ -108,7 +108,8 @@ func EnsureMongoWithoutSetRef(cli client.Client, obj metav1.Object, found client
 	} else if ok {
 		newObj := obj.(*middlewarev1alpha1.MongoDB)
 		oldObj := found.(*middlewarev1alpha1.MongoDB)
-		if newObj.Spec.Members != oldObj.Spec.Members || !reflect.DeepEqual(newObj.Spec.Resources, oldObj.Spec.Resources) {
+		if newObj.Spec.Members != oldObj.Spec.Members || !reflect.DeepEqual(newObj.Spec.Resources, oldObj.Spec.Resources) ||
+			!reflect.DeepEqual(newObj.Spec.PodSpec, oldObj.Spec.PodSpec) || !reflect.DeepEqual(newObj.Spec.ArbiterSpec, oldObj.Spec.ArbiterSpec) {
 			newObj.ResourceVersion = oldObj.ResourceVersion
 			if err := UpsertObject(cli, newObj); err != nil {
 				return err
@@ -165,6 +166,16 @@ func GetMongoInstanceByName(cli client.Client, name, namespace string) (*middlew
 	return mongodb, nil
 }
 
+func GetAddressPool(cli client.Client, name, namespace string) (*middlewarev1alpha1.AddressPool, error) {
+	ctx := context.TODO()
+	pool := &middlewarev1alpha1.AddressPool{}
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	if err := cli.Get(ctx, key, pool); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
 func GetSts(cli client.Client, stsName, namespace string) (*appsv1.StatefulSet, error) {
 	ctx := context.TODO()
 	sts := &appsv1.StatefulSet{}
@@ -377,9 +388,15 @@ func IsExistAndDeleted(client client.Client, name, namespace string, found clien
 
 }
 
+// 缩容时更新和删除成员service的pp，由多集群后端实现
+type PlacementWriter interface {
+	Place(owner *middlewarev1alpha1.MultiCloudMongoDB, pp *karmadaPolicyv1alpha1.PropagationPolicy) error
+	RemovePlacement(name, namespace string) error
+}
+
 // 做缩容用的，需要优化
 func ScaleDownCleaner(cli client.Client,
-	schema *runtime.Scheme,
+	placer PlacementWriter,
 	serviceList []corev1.Service,
 	MultiCloudMongoDB *middlewarev1alpha1.MultiCloudMongoDB,
 	svcPPList *karmadaPolicyv1alpha1.PropagationPolicyList,
@@ -391,10 +408,20 @@ func ScaleDownCleaner(cli client.Client,
 		caw := MultiCloudMongoDB.Status.Result[i].ConnectAddrWithRole
 		cluster := MultiCloudMongoDB.Status.Result[i].Cluster
 		serviceMap := make(map[string]bool, len(serviceList))
+		// 成员集群上报了service与地址的对应关系时直接使用，否则通过nodeport查找
+		addrToService := make(map[string]string, len(MultiCloudMongoDB.Status.Result[i].MemberAddrs))
+		for svcName, addr := range MultiCloudMongoDB.Status.Result[i].MemberAddrs {
+			addrToService[addr] = svcName
+		}
 		for addr, role := range caw {
 			if role == mgo.Arbiter {
 				continue
 			}
+			if svcName, ok := addrToService[addr]; ok {
+				allEffectService[svcName] = true
+				serviceMap[svcName] = true
+				continue
+			}
 			nodePort := strings.Split(addr, ":")[1]
 			port, err := strconv.Atoi(nodePort)
 			if err != nil {
@@ -416,6 +443,10 @@ func ScaleDownCleaner(cli client.Client,
 
 	for i := range serviceList {
 		svc := serviceList[i]
+		// 隐藏成员和延迟成员的service单独维护
+		if svc.Labels[middlewarev1alpha1.LabelKeyMemberKind] != "" {
+			continue
+		}
 		if found := allEffectService[svc.Name]; !found {
 			if err := DeleteObj(cli, &svc); err != nil {
 				log.Errorf("delete svc failed, err: %v", err)
@@ -457,17 +488,18 @@ func ScaleDownCleaner(cli client.Client,
 	for i := range svcPPList.Items {
 		pp := svcPPList.Items[i]
 		if _, found := servicePPMap[pp.Name]; !found {
-			if err := DeleteObj(cli, &pp); err != nil {
+			if err := placer.RemovePlacement(pp.Name, pp.Namespace); err != nil {
 				log.Errorf("delete svc failed, err: %v", err)
 				return err
 			}
+			// 已删除的pp不再更新，否则会以空的集群列表重新创建
+			continue
 		}
 		if !reflect.DeepEqual(pp.Spec.Placement.ClusterAffinity.ClusterNames, servicePPMap[pp.Name]) {
 			log.Infof("now PP/%s clusterNames: %v", pp.Name, pp.Spec.Placement.ClusterAffinity.ClusterNames)
 			log.Infof("servicePPMap PP/%s clusterNames: %v", pp.Name, servicePPMap[pp.Name])
-			foundPP := &karmadaPolicyv1alpha1.PropagationPolicy{}
 			pp.Spec.Placement.ClusterAffinity.ClusterNames = servicePPMap[pp.Name]
-			if err := UpsertPPEnsure(cli, MultiCloudMongoDB, schema, &pp, foundPP); err != nil {
+			if err := placer.Place(MultiCloudMongoDB, &pp); err != nil {
 				log.Errorf("upsert svcpp failed, err: %v", err)
 				return err
 			}
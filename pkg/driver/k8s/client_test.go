@@ -1,6 +1,8 @@
 package k8s
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
 	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
@@ -8,6 +10,7 @@ import (
 	karmadaWorkv1alpha2 "github.com/karmada-io/api/work/v1alpha2"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -18,6 +21,20 @@ import (
 	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
 )
 
+// 直接在控制面上读写pp
+type testPlacer struct {
+	cli    client.Client
+	schema *runtime.Scheme
+}
+
+func (p *testPlacer) Place(owner *middlewarev1alpha1.MultiCloudMongoDB, pp *karmadaPolicyv1alpha1.PropagationPolicy) error {
+	return UpsertPPEnsure(p.cli, owner, p.schema, pp, &karmadaPolicyv1alpha1.PropagationPolicy{})
+}
+
+func (p *testPlacer) RemovePlacement(name, namespace string) error {
+	return IsExistAndDeleted(p.cli, name, namespace, &karmadaPolicyv1alpha1.PropagationPolicy{})
+}
+
 func TestScaleDownCleaner(t *testing.T) {
 	schema := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(schema))
@@ -25,7 +42,6 @@ func TestScaleDownCleaner(t *testing.T) {
 	utilruntime.Must(karmadaPolicyv1alpha1.AddToScheme(schema))
 	utilruntime.Must(karmadaClusterv1alpha1.AddToScheme(schema))
 	utilruntime.Must(karmadaWorkv1alpha2.AddToScheme(schema))
-	cli := fake.NewClientBuilder().WithScheme(schema).Build()
 	serviceList := []corev1.Service{
 		{
 			ObjectMeta: metav1.ObjectMeta{
@@ -173,38 +189,130 @@ func TestScaleDownCleaner(t *testing.T) {
 			},
 		},
 	}
+	loadBalancerMongoDB := MultiCloudMongoDB.DeepCopy()
+	loadBalancerMongoDB.Spec.Expose.Type = middlewarev1alpha1.ExposeTypeLoadBalancer
+	loadBalancerMongoDB.Status.Result = []*middlewarev1alpha1.ServiceTopology{
+		{
+			Cluster: "10-29-14-21",
+			ConnectAddrWithRole: map[string]string{
+				"10.29.6.10:27017": "SECONDARY",
+				"10.29.6.11:27017": "PRIMARY",
+			},
+			MemberAddrs: map[string]string{
+				"multicloudmongodb-sample-mongodb-0": "10.29.6.11:27017",
+				"multicloudmongodb-sample-mongodb-2": "10.29.6.10:27017",
+			},
+		},
+		{
+			Cluster: "10-29-14-25",
+			ConnectAddrWithRole: map[string]string{
+				"10.29.7.10:27017": "SECONDARY",
+			},
+			MemberAddrs: map[string]string{
+				"multicloudmongodb-sample-mongodb-1": "10.29.7.10:27017",
+			},
+		},
+	}
+	// 缩容后成员2不在任何集群上
+	scaledDownMongoDB := MultiCloudMongoDB.DeepCopy()
+	scaledDownMongoDB.Status.Result[0].ConnectAddrWithRole = map[string]string{
+		"10.29.5.103:32594": "PRIMARY",
+	}
 	log := zap.NewExample().Sugar()
 	type args struct {
-		cli               client.Client
-		schema            *runtime.Scheme
 		MultiCloudMongoDB *middlewarev1alpha1.MultiCloudMongoDB
 		svcPPList         *karmadaPolicyv1alpha1.PropagationPolicyList
 		log               *zap.SugaredLogger
 		serviceList       []corev1.Service
 	}
 	tests := []struct {
-		name    string
-		args    args
+		name string
+		args args
+		// 执行后各pp下发的集群，pp已删除时为空
+		want    map[string][]string
 		wantErr bool
 	}{
 		{
 			name: "TestScaleDownCleaner",
 			args: args{
-				cli:               cli,
-				schema:            schema,
 				serviceList:       serviceList,
 				MultiCloudMongoDB: MultiCloudMongoDB,
 				svcPPList:         svcPPList,
 				log:               log,
 			},
+			want: map[string][]string{
+				"multicloudmongodb-sample-mongodb-0-pp": {"10-29-14-21"},
+				"multicloudmongodb-sample-mongodb-1-pp": {"10-29-14-25"},
+				"multicloudmongodb-sample-mongodb-2-pp": {"10-29-14-21"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "TestScaleDownCleanerWithMemberAddrs",
+			args: args{
+				serviceList:       serviceList,
+				MultiCloudMongoDB: loadBalancerMongoDB,
+				svcPPList:         svcPPList,
+				log:               log,
+			},
+			want: map[string][]string{
+				"multicloudmongodb-sample-mongodb-0-pp": {"10-29-14-21"},
+				"multicloudmongodb-sample-mongodb-1-pp": {"10-29-14-25"},
+				"multicloudmongodb-sample-mongodb-2-pp": {"10-29-14-21"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "TestScaleDownCleanerRemovedMember",
+			args: args{
+				serviceList:       serviceList,
+				MultiCloudMongoDB: scaledDownMongoDB,
+				svcPPList:         svcPPList,
+				log:               log,
+			},
+			want: map[string][]string{
+				"multicloudmongodb-sample-mongodb-0-pp": {"10-29-14-21"},
+				"multicloudmongodb-sample-mongodb-1-pp": {"10-29-14-25"},
+				"multicloudmongodb-sample-mongodb-2-pp": nil,
+			},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := ScaleDownCleaner(tt.args.cli, tt.args.schema, tt.args.serviceList, tt.args.MultiCloudMongoDB, tt.args.svcPPList, tt.args.log); (err != nil) != tt.wantErr {
+			objs := make([]client.Object, 0, len(tt.args.serviceList)+len(tt.args.svcPPList.Items))
+			for i := range tt.args.serviceList {
+				objs = append(objs, tt.args.serviceList[i].DeepCopy())
+			}
+			for i := range tt.args.svcPPList.Items {
+				objs = append(objs, tt.args.svcPPList.Items[i].DeepCopy())
+			}
+			cli := fake.NewClientBuilder().WithScheme(schema).WithObjects(objs...).Build()
+			svcPPList := &karmadaPolicyv1alpha1.PropagationPolicyList{}
+			if err := cli.List(context.TODO(), svcPPList); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ScaleDownCleaner(cli, &testPlacer{cli: cli, schema: schema}, tt.args.serviceList, tt.args.MultiCloudMongoDB, svcPPList, tt.args.log); (err != nil) != tt.wantErr {
 				t.Errorf("ScaleDownCleaner() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			for name, clusters := range tt.want {
+				pp := &karmadaPolicyv1alpha1.PropagationPolicy{}
+				err := cli.Get(context.TODO(), client.ObjectKey{Name: name, Namespace: "federation-mongo-operator"}, pp)
+				if clusters == nil {
+					if !errors.IsNotFound(err) {
+						t.Errorf("pp %s not removed, err: %v", name, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("get pp %s err: %v", name, err)
+					continue
+				}
+				if !reflect.DeepEqual(pp.Spec.Placement.ClusterAffinity.ClusterNames, clusters) {
+					t.Errorf("pp %s clusters = %v, want %v", name, pp.Spec.Placement.ClusterAffinity.ClusterNames, clusters)
+				}
+			}
 		})
 	}
 }
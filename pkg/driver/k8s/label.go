@@ -15,6 +15,11 @@ const (
 	Init                    = "app.mongoinit.io/instance"
 	ClusterVip              = "app.mongoclustervip.io/instance"
 	LabelClusterVipInstance = "app.multicloudmongodb.io/vip"
+	MemberOverride          = "app.mongomemberoverride.io/instance"
+	HiddenMemberPP          = "app.mongohiddenmember.io/instance"
+	MemberPriority          = "app.mongomemberpriority.io/instance"
+	MemberAddress           = "app.mongomemberaddress.io/instance"
+	ServiceExport           = "app.mongoserviceexport.io/instance"
 )
 
 func BaseLabel(additionalLabels map[string]string, name string) map[string]string {
@@ -80,6 +85,36 @@ func GenerateClusterVipLabel(additionalLabels map[string]string, name string) ma
 	})
 }
 
+func GenerateMemberOverrideLabel(additionalLabels map[string]string, name string) map[string]string {
+	return MergeLabels(additionalLabels, map[string]string{
+		MemberOverride: name,
+	})
+}
+
+func GenerateMemberPriorityLabel(additionalLabels map[string]string, name string) map[string]string {
+	return MergeLabels(additionalLabels, map[string]string{
+		MemberPriority: name,
+	})
+}
+
+func GenerateMemberAddressLabel(additionalLabels map[string]string, name string) map[string]string {
+	return MergeLabels(additionalLabels, map[string]string{
+		MemberAddress: name,
+	})
+}
+
+func GenerateServiceExportLabel(additionalLabels map[string]string, name string) map[string]string {
+	return MergeLabels(additionalLabels, map[string]string{
+		ServiceExport: name,
+	})
+}
+
+func GenerateHiddenMemberPPLabel(additionalLabels map[string]string, name string) map[string]string {
+	return MergeLabels(additionalLabels, map[string]string{
+		HiddenMemberPP: name,
+	})
+}
+
 func GenerateMongoLabel(additionalLabels map[string]string, name string) map[string]string {
 	return MergeLabels(additionalLabels, map[string]string{
 		Mongo: name,
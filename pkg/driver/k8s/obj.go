@@ -13,16 +13,24 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+var (
+	ServiceExportGVK = schema.GroupVersionKind{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Kind: "ServiceExport"}
+	ServiceImportGVK = schema.GroupVersionKind{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Kind: "ServiceImport"}
+)
+
 const (
 	DefaultPort        = 27017
 	DefaultServiceName = "mongo"
+	ClusterDomain      = "cluster.local"
 )
 
 func SetRefAndCreateObject(owner metav1.Object, obj interface{}, scheme *runtime.Scheme, client client.Client) error {
@@ -78,6 +86,80 @@ func GenerateService(name, namespace string, labels, selector map[string]string,
 	return svc
 }
 
+// 根据暴露方式生成成员service
+func GenerateExposeService(name, namespace string, labels, selector map[string]string, expose middlewarev1alpha1.ExposeSetting) *corev1.Service {
+	// hostNetwork的成员直接使用节点地址，service只用于标识成员
+	headless := expose.GetType() == middlewarev1alpha1.ExposeTypeHeadless ||
+		expose.GetType() == middlewarev1alpha1.ExposeTypeHostNetwork
+	svc := GenerateService(name, namespace, labels, selector, headless)
+	switch expose.GetType() {
+	case middlewarev1alpha1.ExposeTypeLoadBalancer:
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+	case middlewarev1alpha1.ExposeTypeClusterIP, middlewarev1alpha1.ExposeTypeMultiClusterService:
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+	}
+	// 成员之间需要在就绪前互相通信以完成初始同步
+	svc.Spec.PublishNotReadyAddresses = true
+	if len(expose.Annotations) != 0 {
+		svc.Annotations = expose.Annotations
+	}
+
+	return svc
+}
+
+// 集群内service的dns地址
+func ServiceDNSAddr(name, namespace string) string {
+	return fmt.Sprintf("%s.%s.svc.%s:%d", name, namespace, ClusterDomain, DefaultPort)
+}
+
+// karmada为导入的service在成员集群中创建的derived service的域名
+func DerivedServiceHost(name, namespace string) string {
+	return fmt.Sprintf("derived-%s.%s.svc.%s", name, namespace, ClusterDomain)
+}
+
+// 在成员集群中导出service
+func GenerateServiceExport(name, namespace string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ServiceExportGVK)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(labels)
+	return obj
+}
+
+// 在成员集群中导入其他集群导出的service
+func GenerateServiceImport(name, namespace string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ServiceImportGVK)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(labels)
+	obj.Object["spec"] = map[string]interface{}{
+		"type": "ClusterSetIP",
+		"ports": []interface{}{
+			map[string]interface{}{
+				"name":     DefaultServiceName,
+				"port":     int64(DefaultPort),
+				"protocol": string(corev1.ProtocolTCP),
+			},
+		},
+	}
+	return obj
+}
+
+// 获取LoadBalancer分配的地址，同一ingress优先使用ip
+func GetLoadBalancerAddr(svc *corev1.Service) string {
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+	}
+	return ""
+}
+
 func GenerateArbiterService(name, namespace string, labels, selector map[string]string, headless bool) *corev1.Service {
 	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -156,10 +238,20 @@ func GenerateMongo(name, namespace string,
 			},
 			Image:        cr.Spec.ImageSetting.Image,
 			RootPassword: *cr.Spec.Auth.RootPasswd,
+			Expose:       cr.Spec.Expose,
 		},
 	}
 
+	if cr.Spec.PodSpec != nil {
+		mongo.Spec.PodSpec = cr.Spec.PodSpec.DeepCopy()
+	}
+	if cr.Spec.ArbiterSpec != nil {
+		mongo.Spec.ArbiterSpec = cr.Spec.ArbiterSpec.DeepCopy()
+	}
 	if cr.Spec.SpreadConstraints.NodeSelect != nil {
+		if mongo.Spec.PodSpec == nil {
+			mongo.Spec.PodSpec = &middlewarev1alpha1.PodSpec{}
+		}
 		mongo.Spec.PodSpec.NodeSelector = cr.Spec.SpreadConstraints.NodeSelect
 	}
 	if cr.Spec.Config.ConfigRef != nil {
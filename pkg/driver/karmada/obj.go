@@ -2,6 +2,8 @@ package karmada
 
 import (
 	"context"
+	"sort"
+	"strconv"
 
 	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
 	"github.com/karmada-io/api/policy/v1alpha1"
@@ -45,6 +47,91 @@ func GenerateServicePP(name, namespace string, service *corev1.Service, labels m
 	return pp
 }
 
+// 将service和对应的ServiceExport下发到成员集群
+func GenerateServiceExportPP(name, namespace string, service string, labels map[string]string, cluster ...string) *v1alpha1.PropagationPolicy {
+	pp := &v1alpha1.PropagationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: v1alpha1.PropagationSpec{
+			ResourceSelectors: []v1alpha1.ResourceSelector{
+				{
+					APIVersion: "v1",
+					Kind:       "Service",
+					Name:       service,
+				},
+				{
+					APIVersion: "multicluster.x-k8s.io/v1alpha1",
+					Kind:       "ServiceExport",
+					Name:       service,
+				},
+			},
+			Placement: v1alpha1.Placement{
+				ClusterAffinity: &v1alpha1.ClusterAffinity{
+					ClusterNames: cluster,
+				},
+			},
+		},
+	}
+
+	return pp
+}
+
+// 将ServiceImport下发到需要访问service的成员集群
+func GenerateServiceImportPP(name, namespace string, service string, labels map[string]string, cluster ...string) *v1alpha1.PropagationPolicy {
+	pp := &v1alpha1.PropagationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: v1alpha1.PropagationSpec{
+			ResourceSelectors: []v1alpha1.ResourceSelector{
+				{
+					APIVersion: "multicluster.x-k8s.io/v1alpha1",
+					Kind:       "ServiceImport",
+					Name:       service,
+				},
+			},
+			Placement: v1alpha1.Placement{
+				ClusterAffinity: &v1alpha1.ClusterAffinity{
+					ClusterNames: cluster,
+				},
+			},
+		},
+	}
+
+	return pp
+}
+
+func GenerateOpsRequestPP(name, namespace string, ops *middlewarev1alpha1.MongoDBOpsRequest, labels map[string]string, cluster ...string) *v1alpha1.PropagationPolicy {
+	pp := &v1alpha1.PropagationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: v1alpha1.PropagationSpec{
+			ResourceSelectors: []v1alpha1.ResourceSelector{
+				{
+					APIVersion: "middleware.fedstate.io/v1alpha1",
+					Kind:       "MongoDBOpsRequest",
+					Name:       ops.Name,
+				},
+			},
+			Placement: v1alpha1.Placement{
+				ClusterAffinity: &v1alpha1.ClusterAffinity{
+					ClusterNames: cluster,
+				},
+			},
+		},
+	}
+
+	return pp
+}
+
 func GenerateConfigMapPP(name, namespace string, configMap *corev1.ConfigMap, labels map[string]string, cluster ...string) *v1alpha1.PropagationPolicy {
 	pp := &v1alpha1.PropagationPolicy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -109,15 +196,20 @@ func GenerateMongoPP(name, namespace string, labels map[string]string, cr *middl
 
 	for i := range clusterWithReplicaset.ClusterWithReplicaset {
 		cWithSize := clusterWithReplicaset.ClusterWithReplicaset[i]
-		if cWithSize.Replicaset == 0 {
-			break
+		weight := int64(cWithSize.Replicaset)
+		// witness集群分配一个副本保证mongo cr下发，再通过op将members覆盖为0
+		if cWithSize.IsWitness() {
+			weight = 1
+		}
+		if weight == 0 {
+			continue
 		}
 		pp.Spec.Placement.ReplicaScheduling.WeightPreference.StaticWeightList = append(
 			pp.Spec.Placement.ReplicaScheduling.WeightPreference.StaticWeightList, v1alpha1.StaticClusterWeight{
 				TargetCluster: v1alpha1.ClusterAffinity{
 					ClusterNames: []string{cWithSize.Cluster},
 				},
-				Weight: int64(cWithSize.Replicaset),
+				Weight: weight,
 			})
 	}
 
@@ -127,13 +219,24 @@ func GenerateMongoPP(name, namespace string, labels map[string]string, cr *middl
 	return pp
 }
 
-func ListSvcPPByLabel(cli client.Client, label map[string]string) (*v1alpha1.PropagationPolicyList, error) {
-	svcPPList := &v1alpha1.PropagationPolicyList{}
-	ctx, _ := context.WithTimeout(context.Background(), util.CtxTimeout)
-	if err := cli.List(ctx, svcPPList, client.MatchingLabels(label)); err != nil {
+func ListPPByLabel(cli client.Client, namespace string, label map[string]string) (*v1alpha1.PropagationPolicyList, error) {
+	ppList := &v1alpha1.PropagationPolicyList{}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := cli.List(ctx, ppList, client.InNamespace(namespace), client.MatchingLabels(label)); err != nil {
 		return nil, errors2.WithStack(err)
 	}
-	return svcPPList, nil
+	return ppList, nil
+}
+
+func ListOPByLabel(cli client.Client, namespace string, label map[string]string) (*v1alpha1.OverridePolicyList, error) {
+	opList := &v1alpha1.OverridePolicyList{}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := cli.List(ctx, opList, client.InNamespace(namespace), client.MatchingLabels(label)); err != nil {
+		return nil, errors2.WithStack(err)
+	}
+	return opList, nil
 }
 
 func ListClusterByLabel(cli client.Client) (*karmadaClusterv1alpha1.ClusterList, error) {
@@ -200,6 +303,14 @@ func GenerateMongoOPWithPath(name, namespace, clusterName string, labels map[str
 
 }
 
+// 修改指定集群上mongo cr的字段值，value为json格式
+func GenerateMongoOPWithValue(name, namespace, clusterName string, labels map[string]string, cr *middlewarev1alpha1.MultiCloudMongoDB, path string, operator v1alpha1.OverriderOperator, value string) *v1alpha1.OverridePolicy {
+	op := GenerateMongoOPWithPath(name, namespace, clusterName, labels, cr, path)
+	op.Spec.OverrideRules[0].Overriders.Plaintext[0].Operator = operator
+	op.Spec.OverrideRules[0].Overriders.Plaintext[0].Value = apiextensionsv1.JSON{Raw: []byte(value)}
+	return op
+}
+
 func GenerateMongoOPWithLabel(name, namespace, clusterName string, labels map[string]string, cr *middlewarev1alpha1.MultiCloudMongoDB, label map[string]string) *v1alpha1.OverridePolicy {
 	op := &v1alpha1.OverridePolicy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -235,3 +346,64 @@ func GenerateMongoOPWithLabel(name, namespace, clusterName string, labels map[st
 	}
 	return op
 }
+
+// 为指定集群上的mongo cr添加annotation
+func GenerateMongoOPWithAnnotation(name, namespace, clusterName string, labels map[string]string, cr *middlewarev1alpha1.MultiCloudMongoDB, annotation map[string]string) *v1alpha1.OverridePolicy {
+	op := GenerateMongoOPWithLabel(name, namespace, clusterName, labels, cr, annotation)
+	op.Spec.OverrideRules[0].Overriders.AnnotationsOverrider = op.Spec.OverrideRules[0].Overriders.LabelsOverrider
+	op.Spec.OverrideRules[0].Overriders.LabelsOverrider = nil
+	return op
+}
+
+// 为下发到各集群的成员service添加分配的地址，LoadBalancer方式下同时设置loadBalancerIP
+func GenerateServiceAddressOP(name, namespace string, labels map[string]string, service string, clusterToAddr map[string]string, loadBalancer bool) *v1alpha1.OverridePolicy {
+	clusters := make([]string, 0, len(clusterToAddr))
+	for cluster := range clusterToAddr {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	op := &v1alpha1.OverridePolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: v1alpha1.OverrideSpec{
+			ResourceSelectors: []v1alpha1.ResourceSelector{
+				{
+					APIVersion: "v1",
+					Kind:       "Service",
+					Name:       service,
+					Namespace:  namespace,
+				},
+			},
+		},
+	}
+	for _, cluster := range clusters {
+		rule := v1alpha1.RuleWithCluster{
+			TargetCluster: &v1alpha1.ClusterAffinity{
+				ClusterNames: []string{cluster},
+			},
+			Overriders: v1alpha1.Overriders{
+				AnnotationsOverrider: []v1alpha1.LabelAnnotationOverrider{
+					{
+						Operator: v1alpha1.OverriderOpAdd,
+						Value:    map[string]string{middlewarev1alpha1.AnnotationKeyMemberAddress: clusterToAddr[cluster]},
+					},
+				},
+			},
+		}
+		if loadBalancer {
+			rule.Overriders.Plaintext = []v1alpha1.PlaintextOverrider{
+				{
+					Path:     "/spec/loadBalancerIP",
+					Operator: v1alpha1.OverriderOpAdd,
+					Value:    apiextensionsv1.JSON{Raw: []byte(strconv.Quote(clusterToAddr[cluster]))},
+				},
+			}
+		}
+		op.Spec.OverrideRules = append(op.Spec.OverrideRules, rule)
+	}
+	return op
+}
@@ -0,0 +1,15 @@
+package karmada
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// 通过karmada的cluster proxy访问成员集群
+func MemberClusterClient(config *rest.Config, cluster string) (kubernetes.Interface, error) {
+	proxyConfig := rest.CopyConfig(config)
+	proxyConfig.Host = fmt.Sprintf("%s/apis/cluster.karmada.io/v1alpha1/clusters/%s/proxy", config.Host, cluster)
+	return kubernetes.NewForConfig(proxyConfig)
+}
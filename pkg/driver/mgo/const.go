@@ -8,6 +8,8 @@ const (
 	DbAdmin    = "admin"
 	DbLocal    = "local"
 	MaxMembers = 50
+	// 副本集最多7个投票成员
+	MaxVotingMembers = 7
 
 	CmdOk = 1
 
@@ -21,9 +23,16 @@ const (
 	MongoPassword = "MONGO_PASSWORD"
 	MongoRole     = "MONGO_ROLE"
 	MongoDB       = "MONGO_DB"
+	// 轮换中的新密码，修改mongo中的密码前先写入secret，失败重试时使用同一个密码
+	MongoPendingPassword = "MONGO_PENDING_PASSWORD"
+
+	// split horizon名称
+	HorizonInternal = "internal"
+	HorizonExternal = "external"
 )
 
 var (
-	ErrCmdNotOk      = errors2.New("command exec not ok")
-	ErrAlreadyExists = errors2.New("already exists")
+	ErrCmdNotOk       = errors2.New("command exec not ok")
+	ErrAlreadyExists  = errors2.New("already exists")
+	ErrMemberNotFound = errors2.New("member not found in replset config")
 )
@@ -26,6 +26,9 @@ type Member struct {
 	ArbiterOnly  bool        `bson:"arbiterOnly" json:"arbiterOnly"`
 	BuildIndexes bool        `bson:"buildIndexes" json:"buildIndexes"`
 	Hidden       bool        `bson:"hidden" json:"hidden"`
+	// 不同客户端通过TLS SNI获取到不同的成员地址
+	// ref: https://www.mongodb.com/docs/manual/reference/replica-configuration/#mongodb-rsconf-rsconf.members-n-.horizons
+	Horizons map[string]string `bson:"horizons,omitempty" json:"horizons,omitempty"`
 }
 
 type MemberStatus struct {
@@ -36,6 +39,10 @@ type MemberStatus struct {
 	ID             int    `bson:"_id" json:"_id"`
 	Health         int    `bson:"health" json:"health"`
 	State          int    `bson:"state" json:"state"`
+	// 最近一次应用oplog的时间，用于计算复制延迟
+	OptimeDate primitive.DateTime `bson:"optimeDate" json:"-"`
+	// 相对于primary的复制延迟(秒)
+	LagSeconds int64 `bson:"-" json:"lagSeconds,omitempty"`
 }
 type ServerStatusRepl struct {
 	Primary     string `bson:"primary" json:"primary"`
@@ -79,6 +86,12 @@ type OKResponse struct {
 	OK     int    `bson:"ok" json:"ok"`
 }
 
+type ValidateResponse struct {
+	Valid  bool     `bson:"valid" json:"valid"`
+	Errors []string `bson:"errors,omitempty" json:"errors,omitempty"`
+	OK     int      `bson:"ok" json:"ok"`
+}
+
 type RSStatusResponse struct {
 	Members []MemberStatus `bson:"members" json:"members"`
 	OK      int            `bson:"ok" json:"ok"`
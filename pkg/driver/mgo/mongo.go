@@ -284,6 +284,23 @@ func (s *Client) ReplMemberStatus() ([]MemberStatus, error) {
 		return nil, ErrCmdNotOk
 	}
 
+	// 根据primary的optimeDate计算复制延迟
+	for _, m := range resp.Members {
+		if m.StateStr != Primary {
+			continue
+		}
+		for i := range resp.Members {
+			if resp.Members[i].StateStr == Arbiter || resp.Members[i].OptimeDate == 0 {
+				continue
+			}
+			lag := (int64(m.OptimeDate) - int64(resp.Members[i].OptimeDate)) / 1000
+			if lag > 0 {
+				resp.Members[i].LagSeconds = lag
+			}
+		}
+		break
+	}
+
 	return resp.Members, nil
 }
 
@@ -340,6 +357,27 @@ func (s *Client) RemoveMembers(members []Member) error {
 	return nil
 }
 
+// 修改成员的priority，返回修改前的priority
+func (s *Client) SetMemberPriority(host string, priority int) (int, error) {
+	rsConfig, err := s.ReadConfig()
+	if err != nil {
+		return 0, err
+	}
+	for i := range rsConfig.Members {
+		if rsConfig.Members[i].Host != host {
+			continue
+		}
+		old := rsConfig.Members[i].Priority
+		if old == priority {
+			return old, nil
+		}
+		rsConfig.Members[i].Priority = priority
+		rsConfig.Version++
+		return old, s.WriteConfig(rsConfig)
+	}
+	return 0, ErrMemberNotFound
+}
+
 func (s *Client) StepDown() error {
 	resp := &OKResponse{}
 
@@ -360,3 +398,49 @@ func (s *Client) StepDown() error {
 
 	return nil
 }
+
+// 获取除local外的数据库
+func (s *Client) ListDatabaseNames() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	names, err := s.Client.ListDatabaseNames(ctx, bson.D{{Key: "name", Value: bson.D{{Key: "$ne", Value: DbLocal}}}})
+	if err != nil {
+		return nil, errors2.WithStack(err)
+	}
+	return names, nil
+}
+
+func (s *Client) ListCollectionNames(db string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	names, err := s.Database(db).ListCollectionNames(ctx, bson.D{{Key: "type", Value: "collection"}})
+	if err != nil {
+		return nil, errors2.WithStack(err)
+	}
+	return names, nil
+}
+
+// ref: https://www.mongodb.com/docs/manual/reference/command/compact/
+func (s *Client) Compact(ctx context.Context, db, collection string) error {
+	return s.runDBCommand(ctx, db, bson.D{{Key: "compact", Value: collection}, {Key: "force", Value: true}}, &OKResponse{})
+}
+
+// 完整校验集合，返回集合是否正常
+// ref: https://www.mongodb.com/docs/manual/reference/command/validate/
+func (s *Client) Validate(ctx context.Context, db, collection string) (bool, error) {
+	resp := &ValidateResponse{}
+	if err := s.runDBCommand(ctx, db, bson.D{{Key: "validate", Value: collection}, {Key: "full", Value: true}}, resp); err != nil {
+		return false, err
+	}
+	return resp.Valid, nil
+}
+
+// compact、validate等命令执行时间较长，不使用默认的超时时间，由调用方通过ctx限制
+func (s *Client) runDBCommand(ctx context.Context, db string, cmd bson.D, resp interface{}) error {
+	mongoDriverLog.Infof("run mongo command on %s: %v", db, cmd)
+	res := s.Database(db).RunCommand(ctx, cmd)
+	if err := res.Err(); err != nil {
+		return err
+	}
+	return res.Decode(resp)
+}
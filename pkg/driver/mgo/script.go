@@ -26,6 +26,18 @@ const (
 
 	OK = `"ok" : 1`
 
+	// 镜像中没有mongo时使用mongosh，MongoDB 6.0起镜像中只有mongosh
+	MongoShell = `$(command -v mongo || echo mongosh)`
+
+	// 探针使用，不需要认证
+	ProbePing = `db.adminCommand({ping: 1}).ok`
+	// 只有PRIMARY、SECONDARY和ARBITER就绪，副本集未初始化时也认为就绪以便进行初始化
+	ProbeReadiness = `var m = db.isMaster(); if (!(m.ismaster || m.secondary || m.arbiterOnly || !m.setName)) { quit(1); }`
+
+	// preStop使用，primary执行replSetStepDown后等待新的primary选出，%d为最长等待秒数
+	// 脚本放在sh的heredoc中，不能包含单独一行的SCRIPT
+	PreStopStepDown = `var m = db.isMaster(); if (m.ismaster) { try { db.adminCommand({replSetStepDown: 60}); } catch (e) { print(e); } for (var i = 0; i < %d; i++) { m = db.isMaster(); if (m.primary && m.primary != m.me) { break; } sleep(1000); } }`
+
 	// success:
 	// Successfully added user
 	// fail:
@@ -0,0 +1,83 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	karmadaWorkv1alpha2 "github.com/karmada-io/api/work/v1alpha2"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+const (
+	BackendKarmada = "karmada"
+	BackendDirect  = "direct"
+)
+
+// 多集群后端：按pp将控制面上的资源下发到成员集群，按op覆盖各集群上资源的字段，汇总各集群上资源的状态。
+// pp和op使用karmada的policy类型描述，不依赖karmada控制面
+type Backend interface {
+	// 带vip标签的成员集群
+	ListClusters() ([]karmadaClusterv1alpha1.Cluster, error)
+	// 访问成员集群的client
+	ClusterClient(cluster string) (kubernetes.Interface, error)
+
+	// 创建或更新pp，将选择的资源下发到pp中的集群
+	Place(owner *middlewarev1alpha1.MultiCloudMongoDB, pp *karmadaPolicyv1alpha1.PropagationPolicy) error
+	// pp不存在时返回NotFound
+	GetPlacement(name, namespace string) (*karmadaPolicyv1alpha1.PropagationPolicy, error)
+	ListPlacements(namespace string, labels map[string]string) (*karmadaPolicyv1alpha1.PropagationPolicyList, error)
+	// 删除pp，已下发到成员集群的资源随之删除，pp不存在时忽略
+	RemovePlacement(name, namespace string) error
+
+	// 创建或更新op，覆盖已下发资源在各集群上的字段
+	Override(owner *middlewarev1alpha1.MultiCloudMongoDB, op *karmadaPolicyv1alpha1.OverridePolicy) error
+	ListOverrides(namespace string, labels map[string]string) (*karmadaPolicyv1alpha1.OverridePolicyList, error)
+	// 删除op，成员集群上的资源恢复为控制面上的内容，op不存在时忽略
+	RemoveOverride(name, namespace string) error
+
+	// 资源在各成员集群上的状态，资源还未下发时返回NotFound或空列表
+	AggregatedStatus(resource karmadaPolicyv1alpha1.ResourceSelector) ([]karmadaWorkv1alpha2.AggregatedStatusItem, error)
+}
+
+// 按名称创建后端，config为karmada apiserver或控制面集群的配置，namespace为direct后端保存成员集群kubeconfig的namespace
+func NewBackend(name string, cli client.Client, scheme *runtime.Scheme, config *rest.Config, namespace string) (Backend, error) {
+	switch name {
+	case "", BackendKarmada:
+		return NewKarmada(cli, scheme, config), nil
+	case BackendDirect:
+		// 记录和模板在一次调和中多次读写，不使用缓存
+		directCli, err := client.New(config, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, err
+		}
+		return NewDirect(directCli, scheme, namespace), nil
+	default:
+		return nil, fmt.Errorf("unknown fleet backend %s", name)
+	}
+}
+
+func StorageClassExists(backend Backend, cluster, name string) (bool, error) {
+	cli, err := backend.ClusterClient(cluster)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if _, err := cli.StorageV1().StorageClasses().Get(ctx, name, metav1.GetOptions{}); err != nil {
+		if k8serr.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
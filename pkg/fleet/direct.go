@@ -0,0 +1,748 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	karmadaWorkv1alpha2 "github.com/karmada-io/api/work/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/logi"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+var fleetLog = logi.Log.Sugar().Named("fleet")
+
+const (
+	// 保存成员集群kubeconfig的secret，label的值为集群名称，secret的其他label作为集群的label
+	LabelKeyCluster = "fleet.fedstate.io/cluster"
+	// 集群的provider，region和zone使用topology.kubernetes.io的label
+	LabelKeyProvider = "fleet.fedstate.io/provider"
+	KubeconfigKey    = "kubeconfig"
+	// pp和op保存为控制面上的configmap
+	LabelKeyPolicy = "fleet.fedstate.io/policy"
+
+	policyPlacement = "placement"
+	policyOverride  = "override"
+	policyKey       = "policy"
+	appliedKey      = "applied"
+
+	memberTimeout = 10 * time.Second
+	// 同一次调和中多次获取集群列表时不重复探测
+	probeInterval = 10 * time.Second
+)
+
+// 成员集群的client
+type member struct {
+	version   string
+	client    client.Client
+	clientset kubernetes.Interface
+	probeTime time.Time
+	ready     bool
+}
+
+// 不依赖karmada，通过secret中的kubeconfig直接访问成员集群：pp和op保存为控制面上的configmap，
+// 下发时按pp和op渲染出各集群上的资源直接创建或更新，状态从各集群上的资源获取
+type Direct struct {
+	cli       client.Client
+	scheme    *runtime.Scheme
+	namespace string
+
+	lock    sync.Mutex
+	members map[string]*member
+	// 集群Ready状态及其变化的时间，用于故障集群的宽限期。只保存在内存中，重启后宽限期重新计算
+	conditions map[string]metav1.Condition
+	newMember  func(kubeconfig []byte) (*member, error)
+}
+
+func NewDirect(cli client.Client, scheme *runtime.Scheme, namespace string) *Direct {
+	return &Direct{
+		cli:        cli,
+		scheme:     scheme,
+		namespace:  namespace,
+		members:    make(map[string]*member),
+		conditions: make(map[string]metav1.Condition),
+		newMember:  newMember,
+	}
+}
+
+func newMember(kubeconfig []byte) (*member, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = memberTimeout
+	// 集群不可用时也能创建client
+	mapper, err := apiutil.NewDynamicRESTMapper(config, apiutil.WithLazyDiscovery)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := client.New(config, client.Options{Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &member{client: cli, clientset: clientset}, nil
+}
+
+func (d *Direct) ListClusters() ([]karmadaClusterv1alpha1.Cluster, error) {
+	secretList := &corev1.SecretList{}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := d.cli.List(ctx, secretList, client.InNamespace(d.namespace), client.HasLabels{LabelKeyCluster, "vip"}); err != nil {
+		return nil, err
+	}
+
+	clusters := make([]karmadaClusterv1alpha1.Cluster, 0, len(secretList.Items))
+	ready := make([]string, 0, len(secretList.Items))
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		cluster := karmadaClusterv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   secret.Labels[LabelKeyCluster],
+				Labels: secret.Labels,
+			},
+			Spec: karmadaClusterv1alpha1.ClusterSpec{
+				Provider: secret.Labels[LabelKeyProvider],
+				Region:   secret.Labels[corev1.LabelTopologyRegion],
+				Zone:     secret.Labels[corev1.LabelTopologyZone],
+			},
+		}
+		cond := d.readyCondition(cluster.Name, d.probe(cluster.Name, secret))
+		cluster.Status.Conditions = []metav1.Condition{cond}
+		clusters = append(clusters, cluster)
+		if cond.Status == metav1.ConditionTrue {
+			ready = append(ready, cluster.Name)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+	d.removeLeftovers(ready)
+	return clusters, nil
+}
+
+func (d *Direct) probe(cluster string, secret *corev1.Secret) bool {
+	m, err := d.memberFromSecret(cluster, secret)
+	if err != nil {
+		fleetLog.Warnf("create client of cluster %s err: %v", cluster, err)
+		return false
+	}
+	if time.Since(m.probeTime) < probeInterval {
+		return m.ready
+	}
+	_, err = m.clientset.Discovery().ServerVersion()
+	if err != nil {
+		fleetLog.Warnf("probe cluster %s err: %v", cluster, err)
+	}
+	m.probeTime = time.Now()
+	m.ready = err == nil
+	return m.ready
+}
+
+// 集群Ready状态变化时记录变化的时间
+func (d *Direct) readyCondition(cluster string, ready bool) metav1.Condition {
+	cond := metav1.Condition{
+		Type:   karmadaClusterv1alpha1.ClusterConditionReady,
+		Status: metav1.ConditionTrue,
+		Reason: "ClusterReady",
+	}
+	if !ready {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "ClusterNotReachable"
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if last, ok := d.conditions[cluster]; ok && last.Status == cond.Status {
+		cond.LastTransitionTime = last.LastTransitionTime
+		return cond
+	}
+	cond.LastTransitionTime = metav1.Now()
+	d.conditions[cluster] = cond
+	return cond
+}
+
+func (d *Direct) ClusterClient(cluster string) (kubernetes.Interface, error) {
+	m, err := d.member(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return m.clientset, nil
+}
+
+func (d *Direct) member(cluster string) (*member, error) {
+	secretList := &corev1.SecretList{}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := d.cli.List(ctx, secretList, client.InNamespace(d.namespace), client.MatchingLabels{LabelKeyCluster: cluster}); err != nil {
+		return nil, err
+	}
+	if len(secretList.Items) == 0 {
+		return nil, fmt.Errorf("kubeconfig of cluster %s not found", cluster)
+	}
+	return d.memberFromSecret(cluster, &secretList.Items[0])
+}
+
+// kubeconfig不变时复用client
+func (d *Direct) memberFromSecret(cluster string, secret *corev1.Secret) (*member, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if m, ok := d.members[cluster]; ok && m.version == secret.ResourceVersion {
+		return m, nil
+	}
+	kubeconfig, ok := secret.Data[KubeconfigKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no %s", secret.Name, KubeconfigKey)
+	}
+	m, err := d.newMember(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	m.version = secret.ResourceVersion
+	d.members[cluster] = m
+	return m, nil
+}
+
+func (d *Direct) Place(owner *middlewarev1alpha1.MultiCloudMongoDB, pp *karmadaPolicyv1alpha1.PropagationPolicy) error {
+	cm, err := d.getRecord(pp.Name, pp.Namespace, policyPlacement)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	var applied []string
+	if err == nil {
+		if _, applied, err = decodePlacement(cm); err != nil {
+			return err
+		}
+	} else {
+		cm = nil
+	}
+	applied, err = d.sync(pp, applied)
+	if err != nil {
+		return err
+	}
+	return d.saveRecord(owner, cm, pp.Name, pp.Namespace, policyPlacement, pp, applied)
+}
+
+func (d *Direct) GetPlacement(name, namespace string) (*karmadaPolicyv1alpha1.PropagationPolicy, error) {
+	cm, err := d.getRecord(name, namespace, policyPlacement)
+	if err != nil {
+		return nil, err
+	}
+	pp, _, err := decodePlacement(cm)
+	if err != nil {
+		return nil, err
+	}
+	if pp.DeletionTimestamp != nil {
+		return nil, errors.NewNotFound(schema.GroupResource{Group: karmadaPolicyv1alpha1.GroupName, Resource: policyPlacement}, name)
+	}
+	return pp, nil
+}
+
+func (d *Direct) ListPlacements(namespace string, selector map[string]string) (*karmadaPolicyv1alpha1.PropagationPolicyList, error) {
+	cms, err := d.listRecords(namespace, policyPlacement)
+	if err != nil {
+		return nil, err
+	}
+	ppList := &karmadaPolicyv1alpha1.PropagationPolicyList{}
+	for i := range cms {
+		pp, _, err := decodePlacement(&cms[i])
+		if err != nil {
+			return nil, err
+		}
+		if pp.DeletionTimestamp == nil && labels.SelectorFromSet(selector).Matches(labels.Set(pp.Labels)) {
+			ppList.Items = append(ppList.Items, *pp)
+		}
+	}
+	return ppList, nil
+}
+
+// 成员集群不可用时资源无法删除，保留设置了删除时间的记录，集群恢复后继续删除
+func (d *Direct) RemovePlacement(name, namespace string) error {
+	cm, err := d.getRecord(name, namespace, policyPlacement)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	pp, applied, err := decodePlacement(cm)
+	if err != nil {
+		return err
+	}
+	removed := pp.DeepCopy()
+	removed.Spec.Placement = karmadaPolicyv1alpha1.Placement{}
+	if removed.DeletionTimestamp == nil {
+		now := metav1.Now()
+		removed.DeletionTimestamp = &now
+	}
+	return d.removeResources(cm, removed, applied)
+}
+
+// 从已下发的集群删除资源，全部删除后删除记录
+func (d *Direct) removeResources(cm *corev1.ConfigMap, removed *karmadaPolicyv1alpha1.PropagationPolicy, applied []string) error {
+	applied, err := d.sync(removed, applied)
+	if err != nil {
+		return err
+	}
+	if len(applied) != 0 {
+		fleetLog.Warnf("resources of pp %s/%s are left in clusters %v, retry when they are ready", removed.Namespace, removed.Name, applied)
+		return d.saveRecord(nil, cm, removed.Name, removed.Namespace, policyPlacement, removed, applied)
+	}
+	return d.deleteRecord(cm)
+}
+
+// 删除已移除的pp在Ready集群上残留的资源，错误只记录日志
+func (d *Direct) removeLeftovers(ready []string) {
+	cms, err := d.listRecords("", policyPlacement)
+	if err != nil {
+		fleetLog.Warnf("list pp records err: %v", err)
+		return
+	}
+	readySet := toSet(ready)
+	for i := range cms {
+		pp, applied, err := decodePlacement(&cms[i])
+		if err != nil || pp.DeletionTimestamp == nil {
+			continue
+		}
+		for _, cluster := range applied {
+			if !readySet[cluster] {
+				continue
+			}
+			if err := d.removeResources(&cms[i], pp, applied); err != nil {
+				fleetLog.Warnf("remove resources of pp %s/%s err: %v", pp.Namespace, pp.Name, err)
+			}
+			break
+		}
+	}
+}
+
+func (d *Direct) Override(owner *middlewarev1alpha1.MultiCloudMongoDB, op *karmadaPolicyv1alpha1.OverridePolicy) error {
+	cm, err := d.getRecord(op.Name, op.Namespace, policyOverride)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		old, err := decodeOverride(cm)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(old.Spec, op.Spec) && reflect.DeepEqual(old.Labels, op.Labels) {
+			return nil
+		}
+	} else {
+		cm = nil
+	}
+	if err := d.saveRecord(owner, cm, op.Name, op.Namespace, policyOverride, op, nil); err != nil {
+		return err
+	}
+	return d.resync(op.Namespace, op.Spec.ResourceSelectors)
+}
+
+func (d *Direct) ListOverrides(namespace string, selector map[string]string) (*karmadaPolicyv1alpha1.OverridePolicyList, error) {
+	cms, err := d.listRecords(namespace, policyOverride)
+	if err != nil {
+		return nil, err
+	}
+	opList := &karmadaPolicyv1alpha1.OverridePolicyList{}
+	for i := range cms {
+		op, err := decodeOverride(&cms[i])
+		if err != nil {
+			return nil, err
+		}
+		if labels.SelectorFromSet(selector).Matches(labels.Set(op.Labels)) {
+			opList.Items = append(opList.Items, *op)
+		}
+	}
+	return opList, nil
+}
+
+func (d *Direct) RemoveOverride(name, namespace string) error {
+	cm, err := d.getRecord(name, namespace, policyOverride)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	op, err := decodeOverride(cm)
+	if err != nil {
+		return err
+	}
+	if err := d.deleteRecord(cm); err != nil {
+		return err
+	}
+	return d.resync(namespace, op.Spec.ResourceSelectors)
+}
+
+// 资源在pp中各集群上的状态，没有pp下发该资源时返回空列表
+func (d *Direct) AggregatedStatus(resource karmadaPolicyv1alpha1.ResourceSelector) ([]karmadaWorkv1alpha2.AggregatedStatusItem, error) {
+	cms, err := d.listRecords(resource.Namespace, policyPlacement)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cms {
+		pp, applied, err := decodePlacement(&cms[i])
+		if err != nil {
+			return nil, err
+		}
+		if pp.DeletionTimestamp != nil {
+			continue
+		}
+		for _, rs := range pp.Spec.ResourceSelectors {
+			if rs.APIVersion != resource.APIVersion || rs.Kind != resource.Kind || rs.Name != resource.Name {
+				continue
+			}
+			return d.clusterStatus(rs, pp.Namespace, placementClusters(pp), applied), nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *Direct) clusterStatus(rs karmadaPolicyv1alpha1.ResourceSelector, namespace string, clusters, applied []string) []karmadaWorkv1alpha2.AggregatedStatusItem {
+	appliedSet := toSet(applied)
+	items := make([]karmadaWorkv1alpha2.AggregatedStatusItem, 0, len(clusters))
+	for _, cluster := range clusters {
+		item := karmadaWorkv1alpha2.AggregatedStatusItem{ClusterName: cluster}
+		if !appliedSet[cluster] {
+			item.AppliedMessage = "not applied"
+			items = append(items, item)
+			continue
+		}
+		obj, err := d.get(cluster, rs, namespace)
+		if err != nil {
+			item.AppliedMessage = err.Error()
+			items = append(items, item)
+			continue
+		}
+		item.Applied = true
+		if status, ok := obj.Object["status"]; ok {
+			if raw, err := json.Marshal(status); err == nil {
+				item.Status = &runtime.RawExtension{Raw: raw}
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// op变化后重新下发其选择的资源
+func (d *Direct) resync(namespace string, selectors []karmadaPolicyv1alpha1.ResourceSelector) error {
+	cms, err := d.listRecords(namespace, policyPlacement)
+	if err != nil {
+		return err
+	}
+	for i := range cms {
+		pp, applied, err := decodePlacement(&cms[i])
+		if err != nil {
+			return err
+		}
+		// 已移除的pp只在集群Ready后删除残留资源
+		if pp.DeletionTimestamp != nil || !selectsAny(pp, selectors) {
+			continue
+		}
+		if applied, err = d.sync(pp, applied); err != nil {
+			return err
+		}
+		if err := d.saveRecord(nil, &cms[i], pp.Name, pp.Namespace, policyPlacement, pp, applied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将pp选择的资源渲染后下发到pp中的集群，从不再下发的集群中删除，返回已下发的集群。
+// 成员集群的错误只记录日志，下次下发时重试
+func (d *Direct) sync(pp *karmadaPolicyv1alpha1.PropagationPolicy, applied []string) ([]string, error) {
+	clusters := placementClusters(pp)
+	weights := placementWeights(pp)
+	desired := toSet(clusters)
+	wasApplied := toSet(applied)
+	failed := make(map[string]bool)
+	stale := make(map[string]bool)
+
+	for _, rs := range pp.Spec.ResourceSelectors {
+		namespace := rs.Namespace
+		if namespace == "" {
+			namespace = pp.Namespace
+		}
+		template, err := d.template(rs, namespace)
+		if err != nil && !errors.IsNotFound(err) {
+			return applied, err
+		}
+		if err == nil {
+			overrides, err := d.overridesFor(namespace, rs)
+			if err != nil {
+				return applied, err
+			}
+			replicas := divideReplicas(template, weights)
+			for _, cluster := range clusters {
+				obj, err := render(template, cluster, replicas, overrides)
+				if err != nil {
+					return applied, err
+				}
+				if err := d.apply(cluster, obj); err != nil {
+					fleetLog.Warnf("apply %s %s/%s to cluster %s err: %v", rs.Kind, namespace, rs.Name, cluster, err)
+					failed[cluster] = true
+				}
+			}
+		}
+		// 控制面上的资源已删除时从所有集群删除
+		for _, cluster := range applied {
+			if template != nil && desired[cluster] {
+				continue
+			}
+			if err := d.remove(cluster, rs, namespace); err != nil {
+				fleetLog.Warnf("delete %s %s/%s from cluster %s err: %v", rs.Kind, namespace, rs.Name, cluster, err)
+				stale[cluster] = true
+			}
+		}
+	}
+
+	result := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		if !failed[cluster] || wasApplied[cluster] {
+			result = append(result, cluster)
+		}
+	}
+	for _, cluster := range applied {
+		if stale[cluster] && !desired[cluster] {
+			result = append(result, cluster)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (d *Direct) template(rs karmadaPolicyv1alpha1.ResourceSelector, namespace string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(rs.APIVersion)
+	obj.SetKind(rs.Kind)
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := d.cli.Get(ctx, client.ObjectKey{Name: rs.Name, Namespace: namespace}, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// 选择该资源的op，按名称排序
+func (d *Direct) overridesFor(namespace string, rs karmadaPolicyv1alpha1.ResourceSelector) ([]karmadaPolicyv1alpha1.OverridePolicy, error) {
+	opList, err := d.ListOverrides(namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+	ops := make([]karmadaPolicyv1alpha1.OverridePolicy, 0)
+	for i := range opList.Items {
+		for _, selector := range opList.Items[i].Spec.ResourceSelectors {
+			if selectorMatches(selector, rs) {
+				ops = append(ops, opList.Items[i])
+				break
+			}
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Name < ops[j].Name
+	})
+	return ops, nil
+}
+
+func (d *Direct) get(cluster string, rs karmadaPolicyv1alpha1.ResourceSelector, namespace string) (*unstructured.Unstructured, error) {
+	m, err := d.member(cluster)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(rs.APIVersion)
+	obj.SetKind(rs.Kind)
+	ctx, cancel := context.WithTimeout(context.Background(), memberTimeout)
+	defer cancel()
+	if err := m.client.Get(ctx, client.ObjectKey{Name: rs.Name, Namespace: namespace}, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// 不存在时创建，与渲染结果不一致时更新
+func (d *Direct) apply(cluster string, obj *unstructured.Unstructured) error {
+	m, err := d.member(cluster)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), memberTimeout)
+	defer cancel()
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := m.client.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		if errors.IsNotFound(err) {
+			return m.client.Create(ctx, obj)
+		}
+		return err
+	}
+	if equality.Semantic.DeepDerivative(obj.Object, existing.Object) &&
+		sameStrings(obj.GetLabels(), existing.GetLabels()) && sameStrings(obj.GetAnnotations(), existing.GetAnnotations()) {
+		return nil
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return m.client.Update(ctx, obj)
+}
+
+func (d *Direct) remove(cluster string, rs karmadaPolicyv1alpha1.ResourceSelector, namespace string) error {
+	m, err := d.member(cluster)
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(rs.APIVersion)
+	obj.SetKind(rs.Kind)
+	obj.SetName(rs.Name)
+	obj.SetNamespace(namespace)
+	ctx, cancel := context.WithTimeout(context.Background(), memberTimeout)
+	defer cancel()
+	if err := m.client.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// pp和op的记录，名称加上类型后缀避免与pp和op同名的configmap冲突
+func recordName(name, policy string) string {
+	return fmt.Sprintf("%s.%s", name, policy)
+}
+
+func (d *Direct) getRecord(name, namespace, policy string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := d.cli.Get(ctx, client.ObjectKey{Name: recordName(name, policy), Namespace: namespace}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFound(schema.GroupResource{Group: karmadaPolicyv1alpha1.GroupName, Resource: policy}, name)
+		}
+		return nil, err
+	}
+	return cm, nil
+}
+
+func (d *Direct) listRecords(namespace, policy string) ([]corev1.ConfigMap, error) {
+	cmList := &corev1.ConfigMapList{}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := d.cli.List(ctx, cmList, client.InNamespace(namespace), client.MatchingLabels{LabelKeyPolicy: policy}); err != nil {
+		return nil, err
+	}
+	return cmList.Items, nil
+}
+
+// cm为空时创建记录，owner删除时记录随之删除
+func (d *Direct) saveRecord(owner *middlewarev1alpha1.MultiCloudMongoDB, cm *corev1.ConfigMap, name, namespace, policy string, obj interface{}, applied []string) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	values := map[string]string{policyKey: string(data)}
+	if policy == policyPlacement {
+		data, err := json.Marshal(applied)
+		if err != nil {
+			return err
+		}
+		values[appliedKey] = string(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if cm != nil {
+		if reflect.DeepEqual(cm.Data, values) {
+			return nil
+		}
+		cm.Data = values
+		return d.cli.Update(ctx, cm)
+	}
+	cm = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      recordName(name, policy),
+			Namespace: namespace,
+			Labels:    map[string]string{LabelKeyPolicy: policy},
+		},
+		Data: values,
+	}
+	if owner != nil {
+		if err := controllerutil.SetControllerReference(owner, cm, d.scheme); err != nil {
+			return err
+		}
+	}
+	return d.cli.Create(ctx, cm)
+}
+
+func (d *Direct) deleteRecord(cm *corev1.ConfigMap) error {
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := d.cli.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func decodePlacement(cm *corev1.ConfigMap) (*karmadaPolicyv1alpha1.PropagationPolicy, []string, error) {
+	pp := &karmadaPolicyv1alpha1.PropagationPolicy{}
+	if err := json.Unmarshal([]byte(cm.Data[policyKey]), pp); err != nil {
+		return nil, nil, fmt.Errorf("decode pp record %s: %v", cm.Name, err)
+	}
+	var applied []string
+	if data := cm.Data[appliedKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &applied); err != nil {
+			return nil, nil, fmt.Errorf("decode pp record %s: %v", cm.Name, err)
+		}
+	}
+	return pp, applied, nil
+}
+
+func decodeOverride(cm *corev1.ConfigMap) (*karmadaPolicyv1alpha1.OverridePolicy, error) {
+	op := &karmadaPolicyv1alpha1.OverridePolicy{}
+	if err := json.Unmarshal([]byte(cm.Data[policyKey]), op); err != nil {
+		return nil, fmt.Errorf("decode op record %s: %v", cm.Name, err)
+	}
+	return op, nil
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+func sameStrings(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,258 @@
+package fleet
+
+import (
+	"context"
+	"testing"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+)
+
+func TestDirectPlace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(middlewarev1alpha1.AddToScheme(scheme))
+
+	owner := &middlewarev1alpha1.MultiCloudMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default", UID: "demo-uid"},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-hostconf", Namespace: "default", Labels: map[string]string{"app": "demo"}},
+		Data:       map[string]string{"hosts": "a,b"},
+	}
+	objs := []client.Object{owner, cm}
+	members := make(map[string]client.Client)
+	for _, cluster := range []string{"c1", "c2"} {
+		objs = append(objs, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cluster,
+				Namespace: "operators",
+				Labels:    map[string]string{LabelKeyCluster: cluster, "vip": "10.0.0.1", corev1.LabelTopologyRegion: "r1"},
+			},
+			Data: map[string][]byte{KubeconfigKey: []byte(cluster)},
+		})
+		members[cluster] = fake.NewClientBuilder().WithScheme(scheme).Build()
+	}
+	d := NewDirect(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(), scheme, "operators")
+	d.newMember = func(kubeconfig []byte) (*member, error) {
+		return &member{client: members[string(kubeconfig)], clientset: fakeclientset.NewSimpleClientset()}, nil
+	}
+
+	clusters, err := d.ListClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 || clusters[0].Name != "c1" || clusters[0].Spec.Region != "r1" ||
+		!meta.IsStatusConditionTrue(clusters[0].Status.Conditions, karmadaClusterv1alpha1.ClusterConditionReady) {
+		t.Fatalf("ListClusters() = %+v", clusters)
+	}
+
+	op := &karmadaPolicyv1alpha1.OverridePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-hostconf-op", Namespace: "default"},
+		Spec: karmadaPolicyv1alpha1.OverrideSpec{
+			ResourceSelectors: []karmadaPolicyv1alpha1.ResourceSelector{{APIVersion: "v1", Kind: "ConfigMap", Name: cm.Name}},
+			OverrideRules: []karmadaPolicyv1alpha1.RuleWithCluster{
+				{
+					TargetCluster: &karmadaPolicyv1alpha1.ClusterAffinity{ClusterNames: []string{"c2"}},
+					Overriders: karmadaPolicyv1alpha1.Overriders{
+						AnnotationsOverrider: []karmadaPolicyv1alpha1.LabelAnnotationOverrider{
+							{Operator: karmadaPolicyv1alpha1.OverriderOpAdd, Value: map[string]string{"cluster": "c2"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := d.Override(owner, op); err != nil {
+		t.Fatal(err)
+	}
+	pp := karmada.GenerateConfigMapPP("demo-hostconf-pp", cm.Namespace, cm, map[string]string{"app": "demo"}, "c1", "c2")
+	if err := d.Place(owner, pp); err != nil {
+		t.Fatal(err)
+	}
+
+	for cluster, annotation := range map[string]string{"c1": "", "c2": "c2"} {
+		got := &corev1.ConfigMap{}
+		if err := members[cluster].Get(context.TODO(), client.ObjectKeyFromObject(cm), got); err != nil {
+			t.Fatalf("get configmap in cluster %s: %v", cluster, err)
+		}
+		if got.Data["hosts"] != "a,b" || got.Labels["app"] != "demo" || got.Annotations["cluster"] != annotation {
+			t.Errorf("configmap in cluster %s = %+v", cluster, got.ObjectMeta)
+		}
+	}
+
+	status, err := d.AggregatedStatus(karmadaPolicyv1alpha1.ResourceSelector{APIVersion: "v1", Kind: "ConfigMap", Name: cm.Name, Namespace: cm.Namespace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || !status[0].Applied || !status[1].Applied {
+		t.Errorf("AggregatedStatus() = %+v", status)
+	}
+
+	// 从pp中移除集群时删除该集群上的资源
+	pp.Spec.Placement.ClusterAffinity.ClusterNames = []string{"c1"}
+	if err := d.Place(owner, pp); err != nil {
+		t.Fatal(err)
+	}
+	if err := members["c2"].Get(context.TODO(), client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("configmap in cluster c2 not deleted, err: %v", err)
+	}
+	ppList, err := d.ListPlacements(cm.Namespace, map[string]string{"app": "demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ppList.Items) != 1 || len(ppList.Items[0].Spec.Placement.ClusterAffinity.ClusterNames) != 1 {
+		t.Errorf("ListPlacements() = %+v", ppList.Items)
+	}
+
+	if err := d.RemovePlacement(pp.Name, pp.Namespace); err != nil {
+		t.Fatal(err)
+	}
+	if err := members["c1"].Get(context.TODO(), client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("configmap in cluster c1 not deleted, err: %v", err)
+	}
+	if _, err := d.GetPlacement(pp.Name, pp.Namespace); !errors.IsNotFound(err) {
+		t.Errorf("GetPlacement() err = %v, want NotFound", err)
+	}
+}
+
+func TestDirectRemovePlacementRetry(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(middlewarev1alpha1.AddToScheme(scheme))
+
+	owner := &middlewarev1alpha1.MultiCloudMongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default", UID: "demo-uid"},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo-hostconf", Namespace: "default"},
+		Data:       map[string]string{"hosts": "a,b"},
+	}
+	secret := func(cluster string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cluster,
+				Namespace: "operators",
+				Labels:    map[string]string{LabelKeyCluster: cluster, "vip": "10.0.0.1"},
+			},
+			Data: map[string][]byte{KubeconfigKey: []byte(cluster)},
+		}
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(owner, cm, secret("c1"), secret("c2")).Build()
+	members := map[string]client.Client{
+		"c1": fake.NewClientBuilder().WithScheme(scheme).Build(),
+		"c2": fake.NewClientBuilder().WithScheme(scheme).Build(),
+	}
+	d := NewDirect(cli, scheme, "operators")
+	d.newMember = func(kubeconfig []byte) (*member, error) {
+		return &member{client: members[string(kubeconfig)], clientset: fakeclientset.NewSimpleClientset()}, nil
+	}
+
+	pp := karmada.GenerateConfigMapPP("demo-hostconf-pp", cm.Namespace, cm, nil, "c1", "c2")
+	if err := d.Place(owner, pp); err != nil {
+		t.Fatal(err)
+	}
+	clusters, err := d.ListClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transition := clusters[1].Status.Conditions[0].LastTransitionTime
+
+	// 集群c2不可访问时保留记录
+	if err := cli.Delete(context.TODO(), secret("c2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RemovePlacement(pp.Name, pp.Namespace); err != nil {
+		t.Fatal(err)
+	}
+	if err := members["c1"].Get(context.TODO(), client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("configmap in cluster c1 not deleted, err: %v", err)
+	}
+	if _, err := d.GetPlacement(pp.Name, pp.Namespace); !errors.IsNotFound(err) {
+		t.Errorf("GetPlacement() err = %v, want NotFound", err)
+	}
+	if ppList, err := d.ListPlacements(pp.Namespace, nil); err != nil || len(ppList.Items) != 0 {
+		t.Errorf("ListPlacements() = %+v, err: %v", ppList, err)
+	}
+	record := client.ObjectKey{Name: recordName(pp.Name, policyPlacement), Namespace: pp.Namespace}
+	if err := cli.Get(context.TODO(), record, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("pp record deleted with resources left in cluster c2, err: %v", err)
+	}
+
+	// 集群c2恢复后删除残留的资源和记录
+	if err := cli.Create(context.TODO(), secret("c2")); err != nil {
+		t.Fatal(err)
+	}
+	clusters, err = d.ListClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := members["c2"].Get(context.TODO(), client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("configmap in cluster c2 not deleted, err: %v", err)
+	}
+	if err := cli.Get(context.TODO(), record, &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("pp record not deleted, err: %v", err)
+	}
+
+	// Ready状态未变化时保留变化时间，不修改secret
+	if !clusters[1].Status.Conditions[0].LastTransitionTime.Equal(&transition) {
+		t.Errorf("LastTransitionTime = %v, want %v", clusters[1].Status.Conditions[0].LastTransitionTime, transition)
+	}
+	got := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), client.ObjectKey{Name: "c1", Namespace: "operators"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Annotations) != 0 {
+		t.Errorf("secret annotations = %v", got.Annotations)
+	}
+}
+
+func TestDivideReplicas(t *testing.T) {
+	template := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "MongoDB",
+		"spec": map[string]interface{}{"members": int64(5)},
+	}}
+	tests := []struct {
+		name    string
+		weights []clusterWeight
+		want    map[string]int64
+	}{
+		{
+			name:    "equal",
+			weights: []clusterWeight{{cluster: "c2", weight: 1}, {cluster: "c1", weight: 1}},
+			want:    map[string]int64{"c1": 3, "c2": 2},
+		},
+		{
+			name:    "weighted",
+			weights: []clusterWeight{{cluster: "c1", weight: 1}, {cluster: "c2", weight: 3}, {cluster: "c3", weight: 1}},
+			want:    map[string]int64{"c1": 1, "c2": 3, "c3": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := divideReplicas(template, tt.weights)
+			if len(got) != len(tt.want) {
+				t.Fatalf("divideReplicas() = %v, want %v", got, tt.want)
+			}
+			for cluster, n := range tt.want {
+				if got[cluster] != n {
+					t.Errorf("divideReplicas() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
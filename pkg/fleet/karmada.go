@@ -0,0 +1,92 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	karmadaClusterv1alpha1 "github.com/karmada-io/api/cluster/v1alpha1"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	karmadaWorkv1alpha2 "github.com/karmada-io/api/work/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	middlewarev1alpha1 "github.com/fedstate/fedstate/api/v1alpha1"
+	"github.com/fedstate/fedstate/pkg/driver/k8s"
+	"github.com/fedstate/fedstate/pkg/driver/karmada"
+	"github.com/fedstate/fedstate/pkg/util"
+)
+
+// 通过karmada控制面下发资源：pp和op直接创建在karmada apiserver中，状态从ResourceBinding获取
+type Karmada struct {
+	cli    client.Client
+	scheme *runtime.Scheme
+	// karmada apiserver的配置，用于通过cluster proxy访问成员集群
+	config *rest.Config
+}
+
+func NewKarmada(cli client.Client, scheme *runtime.Scheme, config *rest.Config) *Karmada {
+	return &Karmada{cli: cli, scheme: scheme, config: config}
+}
+
+func (k *Karmada) ListClusters() ([]karmadaClusterv1alpha1.Cluster, error) {
+	clusterList, err := karmada.ListClusterByLabel(k.cli)
+	if err != nil {
+		return nil, err
+	}
+	return clusterList.Items, nil
+}
+
+func (k *Karmada) ClusterClient(cluster string) (kubernetes.Interface, error) {
+	if k.config == nil {
+		return nil, fmt.Errorf("karmada apiserver config not set")
+	}
+	return karmada.MemberClusterClient(k.config, cluster)
+}
+
+func (k *Karmada) Place(owner *middlewarev1alpha1.MultiCloudMongoDB, pp *karmadaPolicyv1alpha1.PropagationPolicy) error {
+	return k8s.UpsertPPEnsure(k.cli, owner, k.scheme, pp, &karmadaPolicyv1alpha1.PropagationPolicy{})
+}
+
+func (k *Karmada) GetPlacement(name, namespace string) (*karmadaPolicyv1alpha1.PropagationPolicy, error) {
+	pp := &karmadaPolicyv1alpha1.PropagationPolicy{}
+	ctx, cancel := context.WithTimeout(context.Background(), util.CtxTimeout)
+	defer cancel()
+	if err := k.cli.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, pp); err != nil {
+		return nil, err
+	}
+	return pp, nil
+}
+
+func (k *Karmada) ListPlacements(namespace string, labels map[string]string) (*karmadaPolicyv1alpha1.PropagationPolicyList, error) {
+	return karmada.ListPPByLabel(k.cli, namespace, labels)
+}
+
+func (k *Karmada) RemovePlacement(name, namespace string) error {
+	return k8s.IsExistAndDeleted(k.cli, name, namespace, &karmadaPolicyv1alpha1.PropagationPolicy{})
+}
+
+func (k *Karmada) Override(owner *middlewarev1alpha1.MultiCloudMongoDB, op *karmadaPolicyv1alpha1.OverridePolicy) error {
+	return k8s.UpsertOpEnsure(k.cli, owner, k.scheme, op, &karmadaPolicyv1alpha1.OverridePolicy{})
+}
+
+func (k *Karmada) ListOverrides(namespace string, labels map[string]string) (*karmadaPolicyv1alpha1.OverridePolicyList, error) {
+	return karmada.ListOPByLabel(k.cli, namespace, labels)
+}
+
+func (k *Karmada) RemoveOverride(name, namespace string) error {
+	return k8s.IsExistAndDeleted(k.cli, name, namespace, &karmadaPolicyv1alpha1.OverridePolicy{})
+}
+
+// karmada为每个下发的资源创建名为<name>-<kind>的ResourceBinding
+func (k *Karmada) AggregatedStatus(resource karmadaPolicyv1alpha1.ResourceSelector) ([]karmadaWorkv1alpha2.AggregatedStatusItem, error) {
+	rbName := strings.ToLower(fmt.Sprintf("%s-%s", resource.Name, resource.Kind))
+	rb, err := karmada.GetRBByName(k.cli, rbName, resource.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return rb.Status.AggregatedStatus, nil
+}
@@ -0,0 +1,233 @@
+package fleet
+
+import (
+	"encoding/json"
+	"sort"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	karmadaPolicyv1alpha1 "github.com/karmada-io/api/policy/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// 按静态权重划分副本时副本数所在的字段，与资源解释器(customresourceinterpreter)中的replicaResource一致
+var replicaFields = map[string][]string{
+	"MongoDB": {"spec", "members"},
+}
+
+type clusterWeight struct {
+	cluster string
+	weight  int64
+}
+
+// pp中按静态权重划分副本的集群，不划分副本时返回空
+func placementWeights(pp *karmadaPolicyv1alpha1.PropagationPolicy) []clusterWeight {
+	rs := pp.Spec.Placement.ReplicaScheduling
+	if rs == nil || rs.ReplicaSchedulingType != karmadaPolicyv1alpha1.ReplicaSchedulingTypeDivided ||
+		rs.WeightPreference == nil || len(rs.WeightPreference.StaticWeightList) == 0 {
+		return nil
+	}
+	allowed := toSet(affinityClusters(pp))
+	weights := make([]clusterWeight, 0, len(rs.WeightPreference.StaticWeightList))
+	for _, w := range rs.WeightPreference.StaticWeightList {
+		for _, cluster := range w.TargetCluster.ClusterNames {
+			if len(allowed) == 0 || allowed[cluster] {
+				weights = append(weights, clusterWeight{cluster: cluster, weight: w.Weight})
+			}
+		}
+	}
+	return weights
+}
+
+// 资源下发到的集群，划分副本时只下发到有权重的集群。只支持按名称指定集群
+func placementClusters(pp *karmadaPolicyv1alpha1.PropagationPolicy) []string {
+	if weights := placementWeights(pp); weights != nil {
+		clusters := make([]string, 0, len(weights))
+		for _, w := range weights {
+			clusters = append(clusters, w.cluster)
+		}
+		return clusters
+	}
+	return affinityClusters(pp)
+}
+
+func affinityClusters(pp *karmadaPolicyv1alpha1.PropagationPolicy) []string {
+	if pp.Spec.Placement.ClusterAffinity == nil {
+		return nil
+	}
+	return pp.Spec.Placement.ClusterAffinity.ClusterNames
+}
+
+// 按权重划分资源的副本数，余数依次分给权重大的集群。资源没有副本数时返回空
+func divideReplicas(template *unstructured.Unstructured, weights []clusterWeight) map[string]int64 {
+	path, ok := replicaFields[template.GetKind()]
+	if !ok || len(weights) == 0 {
+		return nil
+	}
+	total, found, err := unstructured.NestedInt64(template.Object, path...)
+	if err != nil || !found {
+		return nil
+	}
+
+	var sum int64
+	for _, w := range weights {
+		sum += w.weight
+	}
+	replicas := make(map[string]int64, len(weights))
+	if sum == 0 {
+		return replicas
+	}
+	remain := total
+	for _, w := range weights {
+		replicas[w.cluster] = total * w.weight / sum
+		remain -= replicas[w.cluster]
+	}
+	order := append([]clusterWeight{}, weights...)
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].weight != order[j].weight {
+			return order[i].weight > order[j].weight
+		}
+		return order[i].cluster < order[j].cluster
+	})
+	for i := 0; remain > 0; i = (i + 1) % len(order) {
+		replicas[order[i].cluster]++
+		remain--
+	}
+	return replicas
+}
+
+// 渲染资源在集群上的内容：去掉控制面上的状态和集群分配的字段，写入划分的副本数，再按名称顺序应用op
+func render(template *unstructured.Unstructured, cluster string, replicas map[string]int64, overrides []karmadaPolicyv1alpha1.OverridePolicy) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{Object: make(map[string]interface{})}
+	for k, v := range template.Object {
+		if k == "metadata" || k == "status" {
+			continue
+		}
+		obj.Object[k] = runtime.DeepCopyJSONValue(v)
+	}
+	obj.SetName(template.GetName())
+	obj.SetNamespace(template.GetNamespace())
+	if l := template.GetLabels(); len(l) != 0 {
+		obj.SetLabels(l)
+	}
+	if a := template.GetAnnotations(); len(a) != 0 {
+		obj.SetAnnotations(a)
+	}
+	// clusterIP由成员集群分配，nodePort与控制面保持一致
+	if obj.GetKind() == "Service" {
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+	}
+	if n, ok := replicas[cluster]; ok {
+		if err := unstructured.SetNestedField(obj.Object, n, replicaFields[obj.GetKind()]...); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range overrides {
+		for _, rule := range overrides[i].Spec.OverrideRules {
+			if !targetsCluster(rule.TargetCluster, cluster) {
+				continue
+			}
+			if err := applyOverriders(obj, rule.Overriders); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return obj, nil
+}
+
+// 没有指定集群时对所有集群生效
+func targetsCluster(affinity *karmadaPolicyv1alpha1.ClusterAffinity, cluster string) bool {
+	if affinity == nil || len(affinity.ClusterNames) == 0 {
+		return true
+	}
+	for _, c := range affinity.ClusterNames {
+		if c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// 支持plaintext、label和annotation的覆盖
+func applyOverriders(obj *unstructured.Unstructured, overriders karmadaPolicyv1alpha1.Overriders) error {
+	if len(overriders.Plaintext) != 0 {
+		patches := make([]map[string]interface{}, 0, len(overriders.Plaintext))
+		for _, p := range overriders.Plaintext {
+			patch := map[string]interface{}{"op": string(p.Operator), "path": p.Path}
+			if p.Operator != karmadaPolicyv1alpha1.OverriderOpRemove {
+				var value interface{}
+				if err := json.Unmarshal(p.Value.Raw, &value); err != nil {
+					return err
+				}
+				patch["value"] = value
+			}
+			patches = append(patches, patch)
+		}
+		patchData, err := json.Marshal(patches)
+		if err != nil {
+			return err
+		}
+		patch, err := jsonpatch.DecodePatch(patchData)
+		if err != nil {
+			return err
+		}
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if data, err = patch.Apply(data); err != nil {
+			return err
+		}
+		patched := &unstructured.Unstructured{}
+		if err := patched.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		obj.Object = patched.Object
+	}
+
+	for _, o := range overriders.LabelsOverrider {
+		obj.SetLabels(overrideMap(obj.GetLabels(), o))
+	}
+	for _, o := range overriders.AnnotationsOverrider {
+		obj.SetAnnotations(overrideMap(obj.GetAnnotations(), o))
+	}
+	return nil
+}
+
+func overrideMap(m map[string]string, o karmadaPolicyv1alpha1.LabelAnnotationOverrider) map[string]string {
+	if m == nil {
+		m = make(map[string]string, len(o.Value))
+	}
+	for k, v := range o.Value {
+		if o.Operator == karmadaPolicyv1alpha1.OverriderOpRemove {
+			delete(m, k)
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
+
+// op的资源选择器是否选择pp下发的资源，op不指定名称时选择同类型的所有资源
+func selectorMatches(selector, rs karmadaPolicyv1alpha1.ResourceSelector) bool {
+	if selector.APIVersion != rs.APIVersion || selector.Kind != rs.Kind {
+		return false
+	}
+	if selector.Namespace != "" && rs.Namespace != "" && selector.Namespace != rs.Namespace {
+		return false
+	}
+	return selector.Name == "" || selector.Name == rs.Name
+}
+
+func selectsAny(pp *karmadaPolicyv1alpha1.PropagationPolicy, selectors []karmadaPolicyv1alpha1.ResourceSelector) bool {
+	for _, rs := range pp.Spec.ResourceSelectors {
+		for _, selector := range selectors {
+			if selectorMatches(selector, rs) {
+				return true
+			}
+		}
+	}
+	return false
+}
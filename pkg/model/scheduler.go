@@ -1,12 +1,21 @@
 package model
 
 const (
-	Id   = "_id"
-	Host = "host"
+	Id      = "_id"
+	Host    = "host"
+	Cluster = "cluster"
+	Service = "service"
+	Kind    = "kind"
+	Delay   = "delay"
 )
 
+// 副本集中拥有投票权的数据成员上限，与mgo.MaxVotingMembers一致，model不依赖mongo驱动
+const MaxVotingMembers = 7
+
 type SchedulerResult struct {
 	ClusterWithReplicaset []clusterWithReplicaset `json:"ClusterWithReplicaset,omitempty"`
+	// 内置调度器计算结果时使用的调度模式，外部调度结果为空
+	Mode string `json:"mode,omitempty"`
 }
 
 type clusterWithReplicaset struct {
@@ -15,6 +24,111 @@ type clusterWithReplicaset struct {
 	Arbiter    bool   `json:"arbiter,omitempty"`
 }
 
+// 标记了仲裁节点但没有数据节点的集群，只部署仲裁节点
+func (c clusterWithReplicaset) IsWitness() bool {
+	return c.Arbiter && c.Replicaset == 0
+}
+
+func (r *SchedulerResult) Append(cluster string, replicaset int) {
+	r.ClusterWithReplicaset = append(r.ClusterWithReplicaset, clusterWithReplicaset{
+		Cluster:    cluster,
+		Replicaset: replicaset,
+	})
+}
+
+// 调度结果中数据节点的总数
+func (r *SchedulerResult) Replicaset() int {
+	total := 0
+	for _, c := range r.ClusterWithReplicaset {
+		total += c.Replicaset
+	}
+	return total
+}
+
+// 仲裁节点所在集群，未标记时返回空
+func (r *SchedulerResult) ArbiterCluster() string {
+	for _, c := range r.ClusterWithReplicaset {
+		if c.Arbiter {
+			return c.Cluster
+		}
+	}
+	return ""
+}
+
+// 仲裁节点所在的witness集群，仲裁节点和数据节点在同一集群时返回空
+func (r *SchedulerResult) WitnessCluster() string {
+	for _, c := range r.ClusterWithReplicaset {
+		if c.IsWitness() {
+			return c.Cluster
+		}
+	}
+	return ""
+}
+
+// 将仲裁节点放到指定集群，集群没有数据节点时作为witness集群
+func (r *SchedulerResult) SetArbiter(cluster string) {
+	list := make([]clusterWithReplicaset, 0, len(r.ClusterWithReplicaset)+1)
+	found := false
+	for _, c := range r.ClusterWithReplicaset {
+		if c.IsWitness() {
+			continue
+		}
+		c.Arbiter = c.Cluster == cluster
+		found = found || c.Arbiter
+		list = append(list, c)
+	}
+	if !found {
+		list = append(list, clusterWithReplicaset{
+			Cluster: cluster,
+			Arbiter: true,
+		})
+	}
+	r.ClusterWithReplicaset = list
+}
+
+// 各集群的投票成员数，开启仲裁节点时计入仲裁节点所在的集群，未标记时和UpsertArbiterHandler一致放在第一个集群。
+// 与成员集群生成副本集配置时一致，只有前7个数据成员拥有投票权，按调度结果中集群的顺序分配
+func (r *SchedulerResult) Votes(arbiter bool) map[string]int {
+	votes := make(map[string]int, len(r.ClusterWithReplicaset))
+	remain := MaxVotingMembers
+	for _, c := range r.ClusterWithReplicaset {
+		n := c.Replicaset
+		if n > remain {
+			n = remain
+		}
+		if n > 0 {
+			votes[c.Cluster] += n
+			remain -= n
+		}
+	}
+	if arbiter && len(r.ClusterWithReplicaset) > 0 {
+		cluster := r.ArbiterCluster()
+		if cluster == "" {
+			cluster = r.ClusterWithReplicaset[0].Cluster
+		}
+		votes[cluster]++
+	}
+	return votes
+}
+
+// 返回持有半数及以上投票的集群(或分组)、其投票数和投票总数，该集群故障后剩余成员不足多数。没有时集群为空
+func MajorityHolder(votes map[string]int) (string, int, int) {
+	total := 0
+	for _, n := range votes {
+		total += n
+	}
+	holder, max := "", 0
+	for group, n := range votes {
+		if n > max || (n == max && group > holder) {
+			holder, max = group, n
+		}
+	}
+	if total == 0 || max*2 < total {
+		return "", max, total
+	}
+	return holder, max, total
+}
+
 type HostConf struct {
 	Arbiters []string `json:"arbiters,omitempty"`
 	Members  []string `json:"datas,omitempty"`
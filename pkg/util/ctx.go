@@ -9,6 +9,8 @@ import (
 const (
 	SyncWaitTime = 10 * time.Second
 	CtxTimeout   = 30 * time.Second
+	// 后台执行的运维操作没有设置超时时间时的最长执行时间
+	OpsJobTimeout = 6 * time.Hour
 )
 
 func TimeoutWrap(timeout time.Duration, fn func() error) error {
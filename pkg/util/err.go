@@ -9,4 +9,6 @@ var (
 	ErrRsInitFailed  = errors.New("init_failed")
 	ErrRsStatusNotOk = errors.New("replSet status not ok")
 	ErrObjSync       = errors.New("sync k8s obj error")
+	// 运维操作无法继续执行
+	ErrOpsFailed = errors.New("ops failed")
 )